@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,6 +17,8 @@ func main() {
 	var wg sync.WaitGroup
 	start := time.Now()
 	client := &http.Client{Timeout: 5 * time.Second}
+	//the API key to send, create one with "key create load write" in the app
+	apiKey := os.Getenv("TODO_API_KEY")
 
 	sem := make(chan struct{}, concurrency)
 	for i := 0; i < totalRequest; i++ {
@@ -26,7 +30,16 @@ func main() {
 
 			data := url.Values{}
 			data.Set("description", fmt.Sprintf("load task %d", n))
-			resp, err := client.PostForm("http://localhost:8080/create", data)
+			req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/create", strings.NewReader(data.Encode()))
+			if err != nil {
+				fmt.Println("Request error", err)
+				return
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+apiKey)
+			}
+			resp, err := client.Do(req)
 			if err != nil {
 				fmt.Println("Request error", err)
 				return
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"todo-cli/auth"
+	"todo-cli/list"
+)
+
+type authUserKey struct{}
+
+// wraps an http.Handler so every request needs an API key, sent as "Authorization: Bearer <key>"
+// or as the password of basic auth so browsers can open the web pages. Browsers send basic
+// auth along with forms of other sites too, so it only changes things for requests from
+// the same origin. GET requests need the read scope and everything else write. A key acts
+// for its own user, only admin keys may pick another one with the X-User header.
+func AuthMiddleware(keys *auth.Keys, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//the about page is public
+		if r.URL.Path == "/about" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := requestToken(r)
+		if !ok {
+			unauthorized(w, r, "missing API key, send it in an Authorization: Bearer header")
+			return
+		}
+		//writes with basic auth have to come from our own pages, else any site could make them
+		if _, _, basic := r.BasicAuth(); basic {
+			if err := crossOrigin.Check(r); err != nil {
+				slog.Warn("Cross-origin request with basic auth refused", "method", r.Method, "path", r.URL.Path, "origin", r.Header.Get("Origin"), "trace_id", GetTraceID(r.Context()))
+				writeProblem(w, r, http.StatusForbidden, "cross-origin "+r.Method+" with basic auth credentials, send the API key in an Authorization: Bearer header")
+				return
+			}
+		}
+		key, err := keys.Verify(token)
+		if err != nil {
+			slog.Warn("Request with an invalid API key", "method", r.Method, "path", r.URL.Path, "error", err, "trace_id", GetTraceID(r.Context()))
+			unauthorized(w, r, err.Error())
+			return
+		}
+
+		need := requiredScope(r)
+		if !key.Scope.Allows(need) {
+			slog.Warn("API key scope too narrow", "key", key.ID, "scope", key.Scope, "needs", need, "path", r.URL.Path, "trace_id", GetTraceID(r.Context()))
+			writeProblem(w, r, http.StatusForbidden, fmt.Sprintf("API key %s has the %s scope, %s %s needs %s", key.ID, key.Scope, r.Method, r.URL.Path, need))
+			return
+		}
+		user := key.User
+		if named := strings.TrimSpace(r.Header.Get(UserHeader)); named != "" {
+			named, err := list.NormalizeUserName(named)
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, err)
+				return
+			}
+			if named != key.User && !key.Scope.Allows(auth.ScopeAdmin) {
+				writeProblem(w, r, http.StatusForbidden, fmt.Sprintf("API key %s acts for user %s only, acting for other users needs the admin scope", key.ID, key.User))
+				return
+			}
+			user = named
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authUserKey{}, user)))
+	})
+}
+
+// browsers send cached basic auth credentials with requests other sites make them send
+var crossOrigin = http.NewCrossOriginProtection()
+
+// the key of a request, from a bearer token or the basic auth password
+func requestToken(r *http.Request) (string, bool) {
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+	if _, password, ok := r.BasicAuth(); ok && password != "" {
+		return password, true
+	}
+	return "", false
+}
+
+// reading needs read, anything that changes items needs write
+func requiredScope(r *http.Request) auth.Scope {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return auth.ScopeRead
+	}
+	return auth.ScopeWrite
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="todo", Basic realm="todo"`)
+	writeProblem(w, r, http.StatusUnauthorized, detail)
+}
+
+// the user AuthMiddleware authenticated, "" when the API is served without keys
+func authUser(ctx context.Context) string {
+	user, _ := ctx.Value(authUserKey{}).(string)
+	return user
+}
@@ -0,0 +1,170 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"todo-cli/api"
+	"todo-cli/auth"
+)
+
+// a multi-user mux behind AuthMiddleware and a fresh keys file
+func authMux(t *testing.T) (http.Handler, *auth.Keys) {
+	keys, err := auth.LoadKeys(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	h := usersHandler(t)
+	//identity comes from the key, the header is only a request to act for another user
+	h.TrustUserHeader = false
+	return api.AuthMiddleware(keys, h.Routes()), keys
+}
+
+func newKey(t *testing.T, keys *auth.Keys, user string, scope auth.Scope) string {
+	token, _, err := keys.Create(string(scope), user, scope)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return token
+}
+
+func serveWithKey(mux http.Handler, token, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_Unauthorized(t *testing.T) {
+	mux, keys := authMux(t)
+	token := newKey(t, keys, "alice", auth.ScopeAdmin)
+
+	w := serve(mux, http.MethodGet, "/get", "")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Content-Type") != "application/problem+json" ||
+		!strings.Contains(w.Body.String(), `"type":"/problems/unauthorized"`) || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("Expected a 401 problem without a key, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serveWithKey(mux, token+"x", http.MethodGet, "/get", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong key, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodGet, "/about", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the about page to stay public, got %d", w.Code)
+	}
+
+	id := strings.Split(token, "_")[1]
+	keys.Revoke(id)
+	if w := serveWithKey(mux, token, http.MethodGet, "/get", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a revoked key, got %d", w.Code)
+	}
+}
+
+func TestAuth_Scopes(t *testing.T) {
+	mux, keys := authMux(t)
+	writer := newKey(t, keys, "alice", auth.ScopeWrite)
+	reader := newKey(t, keys, "alice", auth.ScopeRead)
+	serveWithKey(mux, writer, http.MethodPost, "/items", `{"description": "Keyed"}`)
+
+	for _, target := range []string{"/get", "/list", "/items/0"} {
+		if w := serveWithKey(mux, reader, http.MethodGet, target, ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Keyed") {
+			t.Errorf("GET %s with a read key: expected 200, got %d", target, w.Code)
+		}
+	}
+	w := serveWithKey(mux, reader, http.MethodDelete, "/delete?id=0", "")
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"type":"/problems/forbidden"`) || !strings.Contains(w.Body.String(), "needs write") {
+		t.Errorf("Expected a 403 problem deleting with a read key, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serveWithKey(mux, reader, http.MethodPatch, "/items/0", `{"status": "started"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 patching with a read key, got %d", w.Code)
+	}
+	if w := serveWithKey(mux, writer, http.MethodDelete, "/delete?id=0", ""); w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Keyed") {
+		t.Errorf("Expected a write key to delete, got %d %s", w.Code, w.Body.String())
+	}
+
+	//basic auth with the key as password, for browsers
+	req := httptest.NewRequest(http.MethodGet, "/list", nil)
+	req.SetBasicAuth("anyone", reader)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the key to work as basic auth password, got %d", w.Code)
+	}
+}
+
+func TestAuth_Users(t *testing.T) {
+	mux, keys := authMux(t)
+	alice := newKey(t, keys, "alice", auth.ScopeWrite)
+	bob := newKey(t, keys, "bob", auth.ScopeWrite)
+	admin := newKey(t, keys, "ops", auth.ScopeAdmin)
+	serveWithKey(mux, alice, http.MethodPost, "/items", `{"description": "Alice's"}`)
+
+	//a key acts for its own user, whatever the request claims
+	if w := serveWithKey(mux, bob, http.MethodGet, "/items/0", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected bob's key not to see alice's item, got %d", w.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/items/0", nil)
+	req.Header.Set("Authorization", "Bearer "+bob)
+	req.Header.Set(api.UserHeader, "alice")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a write key naming another user, got %d", w.Code)
+	}
+
+	//admin keys may act for anyone
+	req = httptest.NewRequest(http.MethodGet, "/items/0", nil)
+	req.Header.Set("Authorization", "Bearer "+admin)
+	req.Header.Set(api.UserHeader, "Alice")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Alice's") {
+		t.Errorf("Expected an admin key to read alice's item, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// browsers send basic auth along with forms of other sites, those must not change anything
+func TestAuth_BasicAuthCrossOrigin(t *testing.T) {
+	mux, keys := authMux(t)
+	token := newKey(t, keys, "alice", auth.ScopeWrite)
+	post := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "http://todo.example/create?description=Forged", nil)
+		req.SetBasicAuth("alice", token)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("Sec-Fetch-Site", "cross-site"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-site form, got %d", code)
+	}
+	if code := post("Origin", "https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for another origin, got %d", code)
+	}
+	if w := serveWithKey(mux, token, http.MethodGet, "/items", ""); strings.Contains(w.Body.String(), "Forged") {
+		t.Errorf("Expected no forged item, got %s", w.Body.String())
+	}
+
+	//our own pages and clients that aren't browsers still work
+	if code := post("Sec-Fetch-Site", "same-origin"); code != http.StatusOK {
+		t.Errorf("Expected a same-origin form to work, got %d", code)
+	}
+	if code := post("", ""); code != http.StatusOK {
+		t.Errorf("Expected a request without browser headers to work, got %d", code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.SetBasicAuth("alice", token)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected reads to work from anywhere, got %d", w.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"todo-cli/list"
+)
+
+func (h *Handler) registerDependencyRoutes(mux *http.ServeMux, prefix string) {
+	h.handle(mux, "GET "+prefix+"/items/next", h.HandleNextItems)
+	h.handle(mux, "GET "+prefix+"/items/{id}/dependencies", h.HandleGetDependencies)
+	h.handle(mux, "POST "+prefix+"/items/{id}/dependencies", h.HandleAddDependency)
+	h.handle(mux, "DELETE "+prefix+"/items/{id}/dependencies/{blocker}", h.HandleRemoveDependency)
+}
+
+// GET /items/next, the open items nothing is waiting on. ?all=true returns the
+// whole plan, every open item with its level in topological order.
+func (h *Handler) HandleNextItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	if all, _ := strconv.ParseBool(r.URL.Query().Get("all")); all {
+		plan := list.Plan(items)
+		if plan == nil {
+			plan = []list.PlanStep{}
+		}
+		writeJSON(w, http.StatusOK, plan)
+		return
+	}
+	writeJSON(w, http.StatusOK, list.Next(items))
+}
+
+// GET /items/{id}/dependencies, the chain of blockers and the items this one blocks
+func (h *Handler) HandleGetDependencies(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	deps, err := list.DependenciesOf(items, id)
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, deps)
+}
+
+// POST /items/{id}/dependencies {"blocked_by": 3}
+func (h *Handler) HandleAddDependency(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	var body struct {
+		BlockedBy *int `json:"blocked_by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if body.BlockedBy == nil {
+		writeProblem(w, r, http.StatusBadRequest, "Missing blocked_by", FieldError{Field: "blocked_by", Message: "required"})
+		return
+	}
+	h.patchDependencies(w, r, id, list.ItemPatch{AddBlockedBy: []int{*body.BlockedBy}})
+}
+
+// DELETE /items/{id}/dependencies/{blocker}
+func (h *Handler) HandleRemoveDependency(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	blocker, err := strconv.Atoi(r.PathValue("blocker"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid blocker ID", FieldError{Field: "blocker", Message: "must be an integer"})
+		return
+	}
+	h.patchDependencies(w, r, id, list.ItemPatch{RemoveBlockedBy: []int{blocker}})
+}
+
+// applies patch and answers with the item's dependencies afterwards
+func (h *Handler) patchDependencies(w http.ResponseWriter, r *http.Request, id int, patch list.ItemPatch) {
+	items, err := h.actor(r).Patch(r.Context(), id, patch)
+	if err != nil {
+		slog.Error("Update dependencies failed", "id", id, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	slog.Info("Dependencies updated via API", "id", id, "trace_id", GetTraceID(r.Context()))
+	deps, _ := list.DependenciesOf(items, id)
+	writeJSON(w, http.StatusOK, deps)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-cli/list"
+)
+
+// how often an idle /events stream sends a comment so proxies keep it open
+const DefaultHeartbeat = 15 * time.Second
+
+// streams list changes as Server-Sent Events. A reconnecting client sends the last
+// seen id in Last-Event-ID and gets the retained events it missed; when they are
+// gone it receives a "resync" event and should reload /get.
+func (h *Handler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeProblem(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	var since uint64
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		var err error
+		since, err = strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	traceID := GetTraceID(ctx)
+	//the subscription ends with the request context when the client disconnects
+	events, err := h.actor(r).SubscribeSince(ctx, since)
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	slog.Info("Event stream opened", "last_event_id", since, "trace_id", traceID)
+
+	heartbeat := h.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Event stream closed by client", "trace_id", traceID)
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, ev); err != nil {
+				slog.Warn("Event stream write failed", "trace_id", traceID, "error", err)
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writes one SSE frame. Resync markers carry no id so the client keeps resuming
+// from the last event it actually received.
+func writeEvent(w http.ResponseWriter, ev list.Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if ev.Type != list.EventResync {
+		if _, err := fmt.Fprintf(w, "id: %d\n", ev.Seq); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+	return err
+}
@@ -0,0 +1,180 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo-cli/api"
+	"todo-cli/list"
+)
+
+type sseFrame struct {
+	id      string
+	event   string
+	data    string
+	comment string
+}
+
+// opens /events and returns a channel of parsed frames, closed when the stream ends
+func openEventStream(t *testing.T, ctx context.Context, url, lastEventID string) <-chan sseFrame {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	frames := make(chan sseFrame, 100)
+	go func() {
+		defer close(frames)
+		defer resp.Body.Close()
+		scanner := bufio.NewScanner(resp.Body)
+		var f sseFrame
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				frames <- f
+				f = sseFrame{}
+			case strings.HasPrefix(line, ":"):
+				f.comment = strings.TrimSpace(line[1:])
+			case strings.HasPrefix(line, "id: "):
+				f.id = line[len("id: "):]
+			case strings.HasPrefix(line, "event: "):
+				f.event = line[len("event: "):]
+			case strings.HasPrefix(line, "data: "):
+				f.data = line[len("data: "):]
+			}
+		}
+	}()
+	return frames
+}
+
+func nextFrame(t *testing.T, frames <-chan sseFrame) sseFrame {
+	t.Helper()
+	for {
+		select {
+		case f, ok := <-frames:
+			if !ok {
+				t.Fatal("Event stream ended")
+			}
+			if f.comment != "" {
+				continue //heartbeat
+			}
+			return f
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for event")
+		}
+	}
+}
+
+func doRequest(t *testing.T, method, url string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s %s: expected 200 OK, got %d", method, url, resp.StatusCode)
+	}
+}
+
+func newEventServer(t *testing.T) *httptest.Server {
+	actor := list.NewListActor(nil)
+	h := api.NewHandler(actor)
+	h.Heartbeat = 20 * time.Millisecond
+	srv := httptest.NewServer(h.Routes())
+	t.Cleanup(func() {
+		srv.Close()
+		actor.Stop()
+	})
+	return srv
+}
+
+func TestEvents_StreamsCreateAndDelete(t *testing.T) {
+	srv := newEventServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := openEventStream(t, ctx, srv.URL, "")
+
+	doRequest(t, http.MethodPost, srv.URL+"/create?description=Streamed")
+	doRequest(t, http.MethodDelete, srv.URL+"/delete?id=0")
+
+	added := nextFrame(t, frames)
+	if added.event != "item_added" || added.id != "1" {
+		t.Fatalf("Expected item_added with id 1, got %+v", added)
+	}
+	var ev list.Event
+	if err := json.Unmarshal([]byte(added.data), &ev); err != nil {
+		t.Fatalf("Failed to parse event data: %v", err)
+	}
+	if ev.After == nil || ev.After.Description != "Streamed" {
+		t.Errorf("Expected added item in event, got %+v", ev)
+	}
+
+	deleted := nextFrame(t, frames)
+	if deleted.event != "item_deleted" || deleted.id != "2" {
+		t.Fatalf("Expected item_deleted with id 2, got %+v", deleted)
+	}
+}
+
+func TestEvents_ResumeFromLastEventID(t *testing.T) {
+	srv := newEventServer(t)
+	for i := 0; i < 3; i++ {
+		doRequest(t, http.MethodPost, srv.URL+"/create?description=Task")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := openEventStream(t, ctx, srv.URL, "1")
+
+	for _, want := range []string{"2", "3"} {
+		f := nextFrame(t, frames)
+		if f.id != want {
+			t.Fatalf("Expected replayed event %s, got %+v", want, f)
+		}
+	}
+}
+
+func TestEvents_UnknownLastEventIDGetsResync(t *testing.T) {
+	srv := newEventServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := openEventStream(t, ctx, srv.URL, "99")
+
+	if f := nextFrame(t, frames); f.event != "resync" || f.id != "" {
+		t.Fatalf("Expected resync without id, got %+v", f)
+	}
+}
+
+func TestEvents_Heartbeat(t *testing.T) {
+	srv := newEventServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := openEventStream(t, ctx, srv.URL, "")
+
+	select {
+	case f := <-frames:
+		if f.comment != "heartbeat" {
+			t.Fatalf("Expected heartbeat, got %+v", f)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for heartbeat")
+	}
+}
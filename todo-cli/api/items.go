@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-cli/list"
+	"todo-cli/list/query"
+)
+
+// resource routes, registered next to the legacy verb routes. prefix is "" for the
+// default list or "/lists/{list}" for a named one.
+func (h *Handler) registerItemRoutes(mux *http.ServeMux, prefix string) {
+	h.handle(mux, "GET "+prefix+"/items", h.HandleListItems)
+	h.handleCreate(mux, "POST "+prefix+"/items", h.HandleCreateItem)
+	h.handle(mux, "GET "+prefix+"/items/search", h.HandleSearchItems)
+	h.handle(mux, "GET "+prefix+"/items/{id}", h.HandleGetItem)
+	h.handle(mux, "PATCH "+prefix+"/items/{id}", h.HandlePatchItem)
+	h.handle(mux, "DELETE "+prefix+"/items/{id}", h.HandleDeleteItem)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// applies the ?q= search query, a parse error becomes a problem pointing at its position
+func filterQuery(w http.ResponseWriter, r *http.Request, items []list.Item) ([]list.Item, bool) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return items, true
+	}
+	node, err := query.Parse(q)
+	var perr *query.ParseError
+	if errors.As(err, &perr) {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+perr.Error(),
+			FieldError{Field: "q", Message: perr.Msg, Position: perr.Column()})
+		return nil, false
+	}
+	return query.Filter(items, node), true
+}
+
+func pathID(r *http.Request) (int, error) {
+	return strconv.Atoi(r.PathValue("id"))
+}
+
+// GET /items, ?due_before= narrows it to open items due before that date (soonest first
+// unless ?sort= says otherwise), ?tag=backend&tag=!urgent to items tagged backend but not urgent
+// and ?q= to items matching a search query (see package query)
+func (h *Handler) HandleListItems(w http.ResponseWriter, r *http.Request) {
+	var dueBefore time.Time
+	if v := r.URL.Query().Get("due_before"); v != "" {
+		var err error
+		if dueBefore, err = list.ParseDue(v, time.Local); err != nil || dueBefore.IsZero() {
+			writeProblem(w, r, http.StatusBadRequest, "Invalid due_before",
+				FieldError{Field: "due_before", Message: "use YYYY-MM-DD or RFC 3339"})
+			return
+		}
+	}
+
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	if !dueBefore.IsZero() {
+		items = list.DueBefore(items, dueBefore)
+	}
+	items, ok := filterTags(w, r, items)
+	if !ok {
+		return
+	}
+	if items, ok = filterQuery(w, r, items); !ok {
+		return
+	}
+	if _, ok := sortItems(w, r, items); !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// GET /items/search?q=login+bug&limit=20, full-text search ranked by relevance
+func (h *Handler) HandleSearchItems(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Missing q parameter", FieldError{Field: "q", Message: "required"})
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			writeProblem(w, r, http.StatusBadRequest, "Invalid limit", FieldError{Field: "limit", Message: "must be a positive integer"})
+			return
+		}
+	}
+
+	items, err := h.actor(r).Search(r.Context(), q, limit)
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *Handler) HandleCreateItem(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Description string `json:"description"`
+		// creates a subtask of this item
+		ParentID *int     `json:"parent_id"`
+		Tags     []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if body.Description == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Missing description", FieldError{Field: "description", Message: "required"})
+		return
+	}
+
+	item, err := h.actor(r).AddItem(r.Context(), body.Description, body.ParentID, body.Tags...)
+	if err != nil {
+		slog.Error("Create item failed", "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+
+	slog.Info("Item created via API", "id", item.ID, "trace_id", GetTraceID(r.Context()))
+	w.Header().Set("Location", fmt.Sprintf("%s/items/%d", listPrefix(r), item.ID))
+	writeJSON(w, http.StatusCreated, item)
+}
+
+func (h *Handler) HandleGetItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	item, ok := list.FindItem(items, id)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, fmt.Errorf("item with ID %d %w", id, list.ErrNotFound))
+		return
+	}
+	writeJSON(w, http.StatusOK, item)
+}
+
+func (h *Handler) HandlePatchItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	var patch list.ItemPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if patch.IsEmpty() {
+		writeProblem(w, r, http.StatusBadRequest, "Nothing to update (description, status, notes, due, priority, tags, repeat)")
+		return
+	}
+
+	items, err := h.actor(r).Patch(r.Context(), id, patch)
+	if err != nil {
+		slog.Error("Patch item failed", "id", id, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+
+	item, _ := list.FindItem(items, id)
+	slog.Info("Item updated via API", "id", id, "trace_id", GetTraceID(r.Context()))
+	writeJSON(w, http.StatusOK, item)
+}
+
+// reads ?mode=reparent|cascade, what happens to the subtasks of a deleted item
+func deleteMode(w http.ResponseWriter, r *http.Request) (list.DeleteMode, bool) {
+	mode, err := list.ParseDeleteMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid delete mode", FieldError{Field: "mode", Message: err.Error()})
+		return mode, false
+	}
+	return mode, true
+}
+
+func (h *Handler) HandleDeleteItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	mode, ok := deleteMode(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.actor(r).Delete(r.Context(), id, mode); err != nil {
+		slog.Error("Delete item failed", "id", id, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	slog.Info("Item deleted via API", "id", id, "trace_id", GetTraceID(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
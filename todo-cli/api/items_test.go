@@ -0,0 +1,576 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo-cli/api"
+	"todo-cli/list"
+)
+
+func serve(mux http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestItems_Lifecycle(t *testing.T) {
+	mux := getMux(t)
+
+	w := serve(mux, http.MethodPost, "/items", `{"description": "REST task"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 Created, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/items/0" {
+		t.Errorf("Expected Location /items/0, got %q", loc)
+	}
+	var created list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if created.Description != "REST task" || created.Status != list.StatusNotStarted {
+		t.Errorf("Unexpected created item %+v", created)
+	}
+
+	w = serve(mux, http.MethodPatch, "/items/0", `{"status": "started", "description": "Renamed"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+
+	w = serve(mux, http.MethodGet, "/items/0", "")
+	var item list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &item); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if item.Status != list.StatusStarted || item.Description != "Renamed" {
+		t.Errorf("Patch not applied, got %+v", item)
+	}
+
+	w = serve(mux, http.MethodGet, "/items", "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %d (%v)", len(items), err)
+	}
+
+	if w = serve(mux, http.MethodDelete, "/items/0", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 No Content, got %d", w.Code)
+	}
+	if w = serve(mux, http.MethodGet, "/items/0", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestItems_Errors(t *testing.T) {
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Existing"}`)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"unknown item", http.MethodGet, "/items/42", "", http.StatusNotFound},
+		{"patch unknown item", http.MethodPatch, "/items/42", `{"status": "started"}`, http.StatusNotFound},
+		{"delete unknown item", http.MethodDelete, "/items/42", "", http.StatusNotFound},
+		{"bad id", http.MethodGet, "/items/abc", "", http.StatusBadRequest},
+		{"bad json", http.MethodPost, "/items", `{"description":`, http.StatusBadRequest},
+		{"missing description", http.MethodPost, "/items", `{}`, http.StatusBadRequest},
+		{"empty patch", http.MethodPatch, "/items/0", `{}`, http.StatusBadRequest},
+		{"invalid status", http.MethodPatch, "/items/0", `{"status": "sleeping"}`, http.StatusBadRequest},
+		{"wrong method", http.MethodPut, "/items/0", `{}`, http.StatusMethodNotAllowed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := serve(mux, tc.method, tc.target, tc.body); w.Code != tc.want {
+				t.Errorf("Expected %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+// a rejected patch must not apply any of its fields
+func TestItems_PatchIsAtomic(t *testing.T) {
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Original"}`)
+
+	serve(mux, http.MethodPatch, "/items/0", `{"description": "Changed", "status": "sleeping"}`)
+
+	var item list.Item
+	json.Unmarshal(serve(mux, http.MethodGet, "/items/0", "").Body.Bytes(), &item)
+	if item.Description != "Original" {
+		t.Errorf("Expected description to stay 'Original', got %q", item.Description)
+	}
+}
+
+func TestItems_Due(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"Soon", "Later", "Whenever"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	serve(mux, http.MethodPatch, "/items/0", `{"due": "2026-10-20"}`)
+	serve(mux, http.MethodPatch, "/items/1", `{"due": "2026-12-01T10:00:00Z"}`)
+
+	w := serve(mux, http.MethodGet, "/items?due_before=2026-11-01", "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 0 || items[0].DueAt.IsZero() {
+		t.Errorf("Expected only item 0, got %+v", items)
+	}
+
+	w = serve(mux, http.MethodPatch, "/items/2", `{"due": "someday"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"due"`) {
+		t.Errorf("Expected 400 with a due field error, got %d %s", w.Code, w.Body.String())
+	}
+	w = serve(mux, http.MethodGet, "/items?due_before=soon", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad due_before, got %d", w.Code)
+	}
+}
+
+func TestItems_Sort(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"Low", "Urgent", "None"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	serve(mux, http.MethodPatch, "/items/0", `{"priority": "p3"}`)
+	serve(mux, http.MethodPatch, "/items/1", `{"priority": "P0"}`)
+
+	w := serve(mux, http.MethodGet, "/items?sort=priority,-id", "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(items) != 3 || items[0].ID != 1 || items[1].ID != 0 || items[2].ID != 2 {
+		t.Errorf("Expected order 1, 0, 2, got %+v", items)
+	}
+
+	w = serve(mux, http.MethodGet, "/items?sort=size", "")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"sort"`) {
+		t.Errorf("Expected 400 with a sort field error, got %d %s", w.Code, w.Body.String())
+	}
+	w = serve(mux, http.MethodPatch, "/items/2", `{"priority": "P9"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown priority, got %d", w.Code)
+	}
+}
+
+func TestItems_Tags(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"API", "CSS", "DB"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	serve(mux, http.MethodPatch, "/items/0", `{"tags": ["backend", "urgent"]}`)
+	serve(mux, http.MethodPatch, "/items/1", `{"add_tags": ["frontend"]}`)
+	serve(mux, http.MethodPatch, "/items/2", `{"add_tags": ["be"]}`)
+
+	w := serve(mux, http.MethodPost, "/tags/be/rename", `{"to": "backend"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodGet, "/items?tag=backend&tag=!urgent", "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 2 {
+		t.Errorf("Expected only item 2, got %+v", items)
+	}
+
+	w = serve(mux, http.MethodGet, "/tags", "")
+	var cloud []list.TagCount
+	if err := json.Unmarshal(w.Body.Bytes(), &cloud); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(cloud) != 3 || cloud[0] != (list.TagCount{Tag: "backend", Count: 2}) {
+		t.Errorf("Unexpected tag counts %+v", cloud)
+	}
+
+	w = serve(mux, http.MethodPost, "/tags/merge", `{"from": ["nope"], "into": "backend"}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 merging an unused tag, got %d", w.Code)
+	}
+	w = serve(mux, http.MethodPatch, "/items/0", `{"add_tags": ["two words"]}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"tags"`) {
+		t.Errorf("Expected 400 with a tags field error, got %d %s", w.Code, w.Body.String())
+	}
+
+	//tags given on create are set along with the item, invalid ones add nothing
+	w = serve(mux, http.MethodPost, "/items", `{"description": "Tagged", "tags": ["Backend", "new"]}`)
+	var created list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if w.Code != http.StatusCreated || strings.Join(created.Tags, ",") != "backend,new" {
+		t.Errorf("Expected the item created with its tags, got %d %+v", w.Code, created)
+	}
+	w = serve(mux, http.MethodPost, "/items", `{"description": "Bad", "tags": ["two words"]}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"tags"`) {
+		t.Errorf("Expected 400 with a tags field error, got %d %s", w.Code, w.Body.String())
+	}
+	w = serve(mux, http.MethodGet, "/items", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 4 {
+		t.Errorf("Expected 4 items after the rejected create, got %d (%v)", len(items), err)
+	}
+}
+
+func TestItems_Query(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"Fix login bug", "Login page styles", "Write docs"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	serve(mux, http.MethodPatch, "/items/0", `{"status": "started", "tags": ["backend"]}`)
+	serve(mux, http.MethodPatch, "/items/1", `{"status": "started"}`)
+
+	w := serve(mux, http.MethodGet, "/items?q="+url.QueryEscape(`status:started tag:backend "login bug"`), "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != 0 {
+		t.Errorf("Expected only item 0, got %+v", items)
+	}
+
+	w = serve(mux, http.MethodGet, "/items?q="+url.QueryEscape(`status:started colour:red`), "")
+	var problem api.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to parse problem JSON: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || len(problem.Errors) != 1 ||
+		problem.Errors[0].Field != "q" || problem.Errors[0].Position != 16 {
+		t.Errorf("Expected 400 pointing at column 16 of q, got %d %+v", w.Code, problem)
+	}
+}
+
+func TestItems_Search(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"Fix login bug", "Write docs", "Login page redesign"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	serve(mux, http.MethodPatch, "/items/1", `{"notes": "explain the login flow"}`)
+
+	w := serve(mux, http.MethodGet, "/items/search?q=LOG", "")
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(items) != 3 || items[2].ID != 1 {
+		t.Errorf("Expected all three items with the notes match last, got %+v", items)
+	}
+
+	w = serve(mux, http.MethodGet, "/items/search?q=login+bug&limit=1", "")
+	items = nil
+	json.Unmarshal(w.Body.Bytes(), &items)
+	if len(items) != 1 || items[0].ID != 0 {
+		t.Errorf("Expected only item 0, got %+v", items)
+	}
+
+	for _, target := range []string{"/items/search", "/items/search?q=x&limit=0"} {
+		if w := serve(mux, http.MethodGet, target, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestItems_Subtasks(t *testing.T) {
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Launch"}`)
+	w := serve(mux, http.MethodPost, "/items", `{"description": "Design", "parent_id": 0}`)
+	var sub list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &sub); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if w.Code != http.StatusCreated || !sub.IsChildOf(0) {
+		t.Fatalf("Expected a subtask of 0, got %d %+v", w.Code, sub)
+	}
+
+	w = serve(mux, http.MethodPatch, "/items/0", `{"parent_id": 1}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"parent_id"`) {
+		t.Errorf("Expected 400 for a cycle, got %d %s", w.Code, w.Body.String())
+	}
+	w = serve(mux, http.MethodPost, "/items", `{"description": "Orphan", "parent_id": 42}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown parent, got %d", w.Code)
+	}
+
+	w = serve(mux, http.MethodDelete, "/items/0?mode=sideways", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown mode, got %d", w.Code)
+	}
+	w = serve(mux, http.MethodDelete, "/items/0?mode=cascade", "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodGet, "/items/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the subtask to be deleted with its parent, got %d", w.Code)
+	}
+}
+
+func TestItems_Dependencies(t *testing.T) {
+	mux := getMux(t)
+	for _, desc := range []string{"Design", "Build", "Docs"} {
+		serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+	}
+	w := serve(mux, http.MethodPost, "/items/1/dependencies", `{"blocked_by": 0}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodPost, "/items/0/dependencies", `{"blocked_by": 1}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"blocked_by"`) {
+		t.Errorf("Expected 400 for a cycle, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodPatch, "/items/1", `{"status": "started"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "/problems/conflict") {
+		t.Errorf("Expected 409 starting a blocked item, got %d %s", w.Code, w.Body.String())
+	}
+	w = serve(mux, http.MethodPatch, "/items/1", `{"status": "started", "force": true}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected force to start a blocked item, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodGet, "/items/1/dependencies", "")
+	var deps list.Dependencies
+	if err := json.Unmarshal(w.Body.Bytes(), &deps); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(deps.Chain) != 1 || deps.Chain[0].ID != 0 || deps.Chain[0].Depth != 1 || deps.Ready {
+		t.Errorf("Unexpected dependencies %+v", deps)
+	}
+	if w := serve(mux, http.MethodGet, "/items/42/dependencies", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown item, got %d", w.Code)
+	}
+
+	w = serve(mux, http.MethodGet, "/items/next", "")
+	var next []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(next) != 2 || next[0].ID != 0 || next[1].ID != 2 {
+		t.Errorf("Expected Design and Docs next, got %+v", next)
+	}
+	w = serve(mux, http.MethodGet, "/items/next?all=true", "")
+	var plan []list.PlanStep
+	if err := json.Unmarshal(w.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(plan) != 3 || plan[2].ID != 1 || plan[2].Level != 1 {
+		t.Errorf("Expected Build last at level 1, got %+v", plan)
+	}
+
+	w = serve(mux, http.MethodDelete, "/items/1/dependencies/0", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodGet, "/items/1", ""); strings.Contains(w.Body.String(), "blocked_by") {
+		t.Errorf("Expected no blockers left, got %s", w.Body.String())
+	}
+}
+
+func TestItems_Repeat(t *testing.T) {
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Rotate keys"}`)
+
+	w := serve(mux, http.MethodPatch, "/items/0", `{"repeat": "FREQ=YEARLY"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"repeat"`) {
+		t.Errorf("Expected 400 on repeat, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(mux, http.MethodPatch, "/items/0", `{"repeat": "rrule:freq=weekly;byday=mo", "due": "2026-10-05"}`)
+	var item list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &item); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if w.Code != http.StatusOK || item.Repeat != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Fatalf("Expected the normalized rule, got %d %+v", w.Code, item)
+	}
+
+	serve(mux, http.MethodPatch, "/items/0", `{"status": "completed"}`)
+	w = serve(mux, http.MethodGet, "/items/1", "")
+	var next list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if w.Code != http.StatusOK || next.Repeat != item.Repeat || !next.DueAt.After(item.DueAt) || next.DueAt.Weekday() != time.Monday {
+		t.Errorf("Expected the next Monday occurrence, got %d %+v", w.Code, next)
+	}
+}
+
+func TestItems_Workflow(t *testing.T) {
+	wf, err := list.LoadWorkflow("../workflow.example.json")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	t.Cleanup(list.SetWorkflow(wf))
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Ship it"}`)
+
+	w := serve(mux, http.MethodPatch, "/items/0", `{"status": "completed"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `valid next statuses: \"started\", \"cancelled\"`) {
+		t.Errorf("Expected 409 listing the valid next statuses, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(mux, http.MethodPatch, "/items/0", `{"status": "started"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 OK, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodPatch, "/items/0", `{"status": "in review"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 OK, got %d", w.Code)
+	}
+
+	w = serve(mux, http.MethodGet, "/workflow", "")
+	var got struct {
+		Statuses []string            `json:"statuses"`
+		Next     map[string][]string `json:"next"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(got.Statuses) != 6 || strings.Join(got.Next["in review"], ",") != "started,completed" {
+		t.Errorf("Unexpected workflow %+v", got)
+	}
+}
+
+func TestItems_Lists(t *testing.T) {
+	lists := list.NewLists(func(string) (list.Store, error) { return list.NewMemoryStore(nil), nil }, 0)
+	t.Cleanup(lists.Stop)
+	mux := api.NewListsHandler(lists).Routes()
+	serve(mux, http.MethodPost, "/items", `{"description": "Groceries"}`)
+
+	w := serve(mux, http.MethodPost, "/lists/Work/items", `{"description": "Standup"}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/lists/work/items/0" {
+		t.Fatalf("Expected 201 at /lists/work/items/0, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	serve(mux, http.MethodPost, "/lists/work/items", `{"description": "Review"}`)
+	if w := serve(mux, http.MethodGet, "/items/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected items of work to stay out of the default list, got %d", w.Code)
+	}
+
+	w = serve(mux, http.MethodPost, "/lists/work/items/1/move", `{"list": "default"}`)
+	var moved list.Item
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	if w.Code != http.StatusOK || moved.ID != 1 || w.Header().Get("Location") != "/items/1" {
+		t.Errorf("Expected the item moved to /items/1, got %d %q %+v", w.Code, w.Header().Get("Location"), moved)
+	}
+
+	w = serve(mux, http.MethodGet, "/lists", "")
+	var summaries []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &summaries)
+	if len(summaries) != 2 || summaries[0].Name != "default" || summaries[0].Count != 2 || summaries[1].Count != 1 {
+		t.Errorf("Unexpected lists %+v", summaries)
+	}
+
+	w = serve(mux, http.MethodGet, "/lists/not%20a%20list/items", "")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"list"`) {
+		t.Errorf("Expected 400 for an invalid list name, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(getMux(t), http.MethodGet, "/lists/work/items", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another list on a single list handler, got %d", w.Code)
+	}
+}
+
+// a GET on a list that doesn't exist is 404 and leaves no file or list behind
+func TestItems_UnknownListCreatesNothing(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "items.json")
+	lists := list.NewLists(func(name string) (list.Store, error) {
+		return list.NewFileStore(list.ListFile(dataFile, name), list.DefaultLockTimeout), nil
+	}, 0)
+	lists.Discover = func() ([]string, error) { return list.FindLists(dataFile) }
+	t.Cleanup(lists.Stop)
+	mux := api.NewListsHandler(lists).Routes()
+
+	for _, target := range []string{"/lists/nope/items", "/lists/nope/tags", "/items?list=nope", "/list?list=nope"} {
+		if w := serve(mux, http.MethodGet, target, ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", target, w.Code)
+		}
+	}
+	if w := serve(mux, http.MethodDelete, "/lists/nope/items/0", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting from an unknown list, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodGet, "/lists", ""); strings.Contains(w.Body.String(), "nope") {
+		t.Errorf("Expected no nope list, got %s", w.Body.String())
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*nope*")); len(matches) != 0 {
+		t.Errorf("Expected no files for the unknown list, got %v", matches)
+	}
+
+	//the first write creates it
+	if w := serve(mux, http.MethodPost, "/lists/nope/items", `{"description": "Now it exists"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", w.Code)
+	}
+	if w := serve(mux, http.MethodGet, "/lists/nope/items", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the created list, got %d", w.Code)
+	}
+}
+
+func TestItems_CreateRepliesWithItsOwnItem(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	mine, err := list.NewPersistentListActor(list.NewFileStore(file, list.DefaultLockTimeout), 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	t.Cleanup(mine.Stop)
+	theirs, err := list.NewPersistentListActor(list.NewFileStore(file, list.DefaultLockTimeout), 0)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	t.Cleanup(theirs.Stop)
+	mux := api.NewHandler(mine).Routes()
+
+	//another process wrote first, so the creates below are merged in one group commit
+	if _, err := theirs.Add(context.Background(), "Theirs"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	descs := []string{"First", "Second", "Third"}
+	created := make([]list.Item, len(descs))
+	done := make(chan struct{})
+	for i, desc := range descs {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			w := serve(mux, http.MethodPost, "/items", `{"description": "`+desc+`"}`)
+			if w.Code != http.StatusCreated {
+				t.Errorf("Expected 201 Created, got %d", w.Code)
+				return
+			}
+			json.Unmarshal(w.Body.Bytes(), &created[i])
+			if loc, want := w.Header().Get("Location"), "/items/"+strconv.Itoa(created[i].ID); loc != want {
+				t.Errorf("Expected Location %s, got %q", want, loc)
+			}
+		}()
+	}
+	for range descs {
+		<-done
+	}
+
+	seen := map[int]bool{}
+	for i, item := range created {
+		if item.Description != descs[i] {
+			t.Errorf("Create of %q replied with %+v", descs[i], item)
+		}
+		if item.ID == 0 || seen[item.ID] {
+			t.Errorf("Expected a new ID for %q, got %d", descs[i], item.ID)
+		}
+		seen[item.ID] = true
+	}
+}
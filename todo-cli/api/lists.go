@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"todo-cli/list"
+)
+
+// the list a request works on: {list} in the path, else ?list=, else the default list
+func listName(r *http.Request) string {
+	if name := r.PathValue("list"); name != "" {
+		return name
+	}
+	if name := r.URL.Query().Get("list"); name != "" {
+		return name
+	}
+	return list.DefaultList
+}
+
+// "" for the default list, "/lists/work" for the others, the prefix of its item URLs
+func listPrefix(r *http.Request) string {
+	name, err := list.NormalizeListName(listName(r))
+	if err != nil || name == list.DefaultList {
+		return ""
+	}
+	return "/lists/" + name
+}
+
+// registers fn for pattern with the user's lists and the actor of the request's list
+// resolved up front, an unknown user or an unknown or invalid list name never reaches fn
+func (h *Handler) handle(mux *http.ServeMux, pattern string, fn http.HandlerFunc) {
+	h.handleScoped(mux, pattern, false, fn)
+}
+
+// like handle, but a POST to a user or list that doesn't exist yet creates it
+func (h *Handler) handleCreate(mux *http.ServeMux, pattern string, fn http.HandlerFunc) {
+	h.handleScoped(mux, pattern, true, fn)
+}
+
+func (h *Handler) handleScoped(mux *http.ServeMux, pattern string, create bool, fn http.HandlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		user, ok := h.requestUser(r)
+		if !ok {
+			writeProblem(w, r, http.StatusUnauthorized, UserHeader+" header is not trusted by this server, authenticate with an API key")
+			return
+		}
+		if user == "" {
+			if h.RequireUser {
+				writeProblem(w, r, http.StatusUnauthorized, "missing "+UserHeader+" header, every request must name its user")
+				return
+			}
+			user = list.DefaultUser
+		}
+		getLists, getActor := h.users.Get, (*list.Lists).Get
+		switch {
+		case create && r.Method == http.MethodPost:
+			getLists, getActor = h.users.Create, (*list.Lists).Create
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+			//a user without data yet reads an empty default list
+			getLists = h.users.View
+		}
+		lists, err := getLists(user)
+		if err != nil {
+			writeError(w, r, listErrorStatus(err), err)
+			return
+		}
+		actor, err := getActor(lists, listName(r))
+		if err != nil {
+			writeError(w, r, listErrorStatus(err), err)
+			return
+		}
+		fn(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, scope{lists: lists, actor: actor})))
+	})
+}
+
+// the actor resolved by handle, the default list for handlers called directly
+func (h *Handler) actor(r *http.Request) *list.ListActor {
+	if s, ok := r.Context().Value(scopeKey{}).(scope); ok {
+		return s.actor
+	}
+	actor, _ := h.lists(r).Get(list.DefaultList)
+	return actor
+}
+
+// the lists of the user resolved by handle, the default user's for handlers called directly
+func (h *Handler) lists(r *http.Request) *list.Lists {
+	if s, ok := r.Context().Value(scopeKey{}).(scope); ok {
+		return s.lists
+	}
+	lists, _ := h.users.Get(list.DefaultUser)
+	return lists
+}
+
+func listErrorStatus(err error) int {
+	if errors.Is(err, list.ErrListNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, list.ErrUserNotFound) {
+		return http.StatusNotFound
+	}
+	return errorStatus(err)
+}
+
+func (h *Handler) registerListRoutes(mux *http.ServeMux, prefix string) {
+	h.handle(mux, "POST "+prefix+"/items/{id}/move", h.HandleMoveItem)
+}
+
+// one entry of GET /lists
+type listSummary struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GET /lists, every list with the number of items in it
+func (h *Handler) HandleLists(w http.ResponseWriter, r *http.Request) {
+	names, err := h.lists(r).Names()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	summaries := make([]listSummary, 0, len(names))
+	for _, name := range names {
+		actor, err := h.lists(r).Get(name)
+		if err != nil {
+			writeError(w, r, listErrorStatus(err), err)
+			return
+		}
+		items, err := actor.GetAll(r.Context())
+		if err != nil {
+			writeError(w, r, errorStatus(err), err)
+			return
+		}
+		summaries = append(summaries, listSummary{Name: name, Count: len(items)})
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// POST /items/{id}/move {"list": "work"}, moves the item to another list where it gets a new ID
+func (h *Handler) HandleMoveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	var body struct {
+		List string `json:"list"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if body.List == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Missing list", FieldError{Field: "list", Message: "required"})
+		return
+	}
+
+	item, err := h.lists(r).Move(r.Context(), id, listName(r), body.List)
+	if err != nil {
+		slog.Error("Move item failed", "id", id, "to", body.List, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	to, _ := list.NormalizeListName(body.List)
+	slog.Info("Item moved via API", "id", id, "to", to, "new_id", item.ID, "trace_id", GetTraceID(r.Context()))
+	location := fmt.Sprintf("/items/%d", item.ID)
+	if to != list.DefaultList {
+		location = "/lists/" + to + location
+	}
+	w.Header().Set("Location", location)
+	writeJSON(w, http.StatusOK, item)
+}
@@ -6,12 +6,9 @@ import (
 	"encoding/hex"
 	"log/slog"
 	"net/http"
+	"todo-cli/trace"
 )
 
-type contextkey string
-
-const traceIDKey contextkey = "traceID"
-
 // creates a random 8-byte hex string
 func generateTraceID() string {
 	b := make([]byte, 8)
@@ -25,7 +22,7 @@ func generateTraceID() string {
 func TraceMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		traceID := generateTraceID()
-		ctx := context.WithValue(r.Context(), traceIDKey, traceID)
+		ctx := trace.WithTraceID(r.Context(), traceID)
 		r = r.WithContext(ctx)
 
 		slog.Info("Incoming request",
@@ -46,9 +43,5 @@ func TraceMiddleware(next http.Handler) http.Handler {
 
 // extract the TraceID from context,when present
 func GetTraceID(ctx context.Context) string {
-	traceID, ok := ctx.Value(traceIDKey).(string)
-	if !ok {
-		return "no-trace"
-	}
-	return traceID
+	return trace.GetTraceID(ctx)
 }
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"todo-cli/list"
+)
+
+// one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// 1-based column of the error within the field's value, when known
+	Position int `json:"position,omitempty"`
+}
+
+// RFC 7807 problem details, the body of every API error response
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	TraceID  string       `json:"trace_id"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// problem types for the errors clients are expected to handle, anything else is about:blank
+func problemType(status int, fields []FieldError) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "/problems/unauthorized"
+	case status == http.StatusForbidden:
+		return "/problems/forbidden"
+	case status == http.StatusNotFound:
+		return "/problems/not-found"
+	case status == http.StatusConflict:
+		return "/problems/conflict"
+	case len(fields) > 0:
+		return "/problems/validation"
+	case status >= http.StatusInternalServerError:
+		return "/problems/server-error"
+	}
+	return "about:blank"
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, fields ...FieldError) {
+	p := Problem{
+		Type:     problemType(status, fields),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		TraceID:  GetTraceID(r.Context()),
+		Errors:   fields,
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writes err as a problem, pointing typed validation errors at the field they came from
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	var fields []FieldError
+	if errors.Is(err, list.ErrInvalidStatus) {
+		fields = append(fields, FieldError{Field: "status", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidDue) {
+		fields = append(fields, FieldError{Field: "due", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidPriority) {
+		fields = append(fields, FieldError{Field: "priority", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidTag) {
+		fields = append(fields, FieldError{Field: "tags", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidParent) || errors.Is(err, list.ErrCycle) {
+		fields = append(fields, FieldError{Field: "parent_id", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidDependency) || errors.Is(err, list.ErrDependencyCycle) {
+		fields = append(fields, FieldError{Field: "blocked_by", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrBlocked) || errors.Is(err, list.ErrInvalidTransition) {
+		fields = append(fields, FieldError{Field: "status", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidRule) {
+		fields = append(fields, FieldError{Field: "repeat", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidList) {
+		fields = append(fields, FieldError{Field: "list", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidUser) {
+		fields = append(fields, FieldError{Field: "user", Message: err.Error()})
+	}
+	if errors.Is(err, list.ErrInvalidSort) {
+		fields = append(fields, FieldError{Field: "sort", Message: err.Error()})
+	}
+	writeProblem(w, r, status, err.Error(), fields...)
+}
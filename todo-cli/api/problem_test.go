@@ -0,0 +1,78 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo-cli/api"
+	"todo-cli/list"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) api.Problem {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Expected application/problem+json, got %q", ct)
+	}
+	var p api.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Failed to parse problem: %v", err)
+	}
+	if p.Status != w.Code {
+		t.Errorf("Problem status %d does not match response code %d", p.Status, w.Code)
+	}
+	return p
+}
+
+func TestProblem_NotFoundCarriesTraceID(t *testing.T) {
+	handler := api.TraceMiddleware(getMux(t))
+
+	w := serve(handler, http.MethodGet, "/items/42", "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", w.Code)
+	}
+	p := decodeProblem(t, w)
+	if p.Type != "/problems/not-found" || p.Title != "Not Found" || p.Instance != "/items/42" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+	if p.TraceID == "" || p.TraceID != w.Header().Get("X-Trace-ID") {
+		t.Errorf("Expected trace ID %q, got %q", w.Header().Get("X-Trace-ID"), p.TraceID)
+	}
+}
+
+func TestProblem_InvalidStatusFieldError(t *testing.T) {
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Task"}`)
+
+	w := serve(mux, http.MethodPatch, "/items/0", `{"status": "sleeping"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+	p := decodeProblem(t, w)
+	if p.Type != "/problems/validation" || len(p.Errors) != 1 || p.Errors[0].Field != "status" {
+		t.Errorf("Expected a status field error, got %+v", p)
+	}
+}
+
+func TestProblem_LegacyMissingParameters(t *testing.T) {
+	w := serve(getMux(t), http.MethodPut, "/update?id=1", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+	p := decodeProblem(t, w)
+	if len(p.Errors) != 2 || p.Errors[0].Field != "field" || p.Errors[1].Field != "value" {
+		t.Errorf("Expected field errors for field and value, got %+v", p.Errors)
+	}
+}
+
+func TestProblem_StorageFailure(t *testing.T) {
+	mux := newTestHandler(t, failingStore{list.NewMemoryStore(nil)}).Routes()
+
+	w := serve(mux, http.MethodPost, "/items", `{"description": "Task"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", w.Code)
+	}
+	if p := decodeProblem(t, w); p.Type != "/problems/server-error" || p.Detail == "" {
+		t.Errorf("Unexpected problem %+v", p)
+	}
+}
@@ -1,14 +1,22 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"html/template"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"time"
+	"todo-cli/auth"
 	"todo-cli/list"
+	"todo-cli/list/query"
 )
 
 // ensures that templates load correctly in both runtime and test modes(I was having trouble with test mode)
@@ -25,52 +33,293 @@ func resolvePath(relPath string) string {
 	return relPath
 }
 
+// failures to persist are the server's fault, unknown items are 404, anything else is a bad request
+func errorStatus(err error) int {
+	if errors.Is(err, list.ErrLockTimeout) || errors.Is(err, list.ErrActorStopped) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return http.StatusServiceUnavailable
+	}
+	if list.IsStorageError(err) {
+		return http.StatusInternalServerError
+	}
+	if errors.Is(err, list.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	//valid request, but the item's blockers are still open or its status can't move there
+	if errors.Is(err, list.ErrBlocked) || errors.Is(err, list.ErrInvalidTransition) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// Handler serves the API from one ListActor per list and user, so concurrent requests are
+// serialized by the actor instead of racing on the data file. Persistence is the
+// actor's job, a reply only comes back once the change is saved.
+type Handler struct {
+	users *list.Users
+	// reject requests without an X-User header instead of serving them as the default user
+	RequireUser bool
+	// take the user of requests without an API key from their X-User header. The header
+	// is no credential, anyone reaching the server could act for any user, so set it
+	// only behind a proxy that authenticates users and sets the header itself.
+	// Otherwise such requests are refused.
+	TrustUserHeader bool
+	// interval between /events heartbeats, zero uses DefaultHeartbeat
+	Heartbeat time.Duration
+}
+
+// serves actor as the default list and no other
+func NewHandler(actor *list.ListActor) *Handler {
+	return NewListsHandler(list.SingleList(actor))
+}
+
+// serves lists as the lists of the default user and no other user
+func NewListsHandler(lists *list.Lists) *Handler {
+	return NewUsersHandler(list.SingleUser(lists))
+}
+
+// serves the lists of every user, each request acting for the user of its API key (see
+// AuthMiddleware) or of its X-User header (see TrustUserHeader)
+func NewUsersHandler(users *list.Users) *Handler {
+	return &Handler{users: users}
+}
+
+// registers the API and web routes
+func (h *Handler) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	//every resource route exists for the default list and under /lists/{list}
+	for _, prefix := range []string{"", "/lists/{list}"} {
+		h.registerItemRoutes(mux, prefix)
+		h.registerTagRoutes(mux, prefix)
+		h.registerDependencyRoutes(mux, prefix)
+		h.registerListRoutes(mux, prefix)
+	}
+	h.handle(mux, "GET /lists", h.HandleLists)
+	mux.HandleFunc("GET /workflow", HandleWorkflow)
+
+	//legacy verb routes, kept for existing clients. ?list= picks another list.
+	h.handleCreate(mux, "/create", h.HandleCreate)
+	h.handle(mux, "/get", h.HandleGet)
+	h.handle(mux, "/update", h.HandleUpdate)
+	h.handle(mux, "/delete", h.HandleDelete)
+	h.handle(mux, "/events", h.HandleEvents)
+
+	//web routes
+	mux.HandleFunc("/about", HandleAbout)
+	h.handle(mux, "/list", h.HandleListPage)
+	h.handle(mux, "POST /list/status", h.HandleListStatus)
+	return mux
+}
+
+// helpers available to the web templates
+var templateFuncs = template.FuncMap{
+	"fmtTime":  list.FormatTime,
+	"overdue":  func(item list.Item) bool { return item.IsOverdue(list.Now()) },
+	"link":     pageLink,
+	"relink":   relink,
+	"sortLink": sortLink,
+	"sortMark": sortMark,
+	"has":      slices.Contains[[]string],
+	//left padding of a description cell, subtasks step in per level
+	"indent": func(depth int) int { return 8 + 24*depth },
+	//statuses the workflow lets an item move to, for the status form of each row
+	"nextStatuses": func(status string) []string { return list.CurrentWorkflow().Next(status) },
+}
+
+// URL of a web page on a list with the given query parameters (name, value, ...).
+// The default list needs no parameter, so its links stay as they were before lists.
+func pageLink(path, listName string, params ...string) string {
+	values := url.Values{}
+	if listName != list.DefaultList {
+		values.Set("list", listName)
+	}
+	for i := 0; i+1 < len(params); i += 2 {
+		values.Add(params[i], params[i+1])
+	}
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
+
+// URL of path with the query of the current page, only key changed to value (or
+// dropped when value is empty), so a link keeps the list, filters and sort in effect
+func relink(path string, current url.Values, key, value string) string {
+	values := maps.Clone(current)
+	if values == nil {
+		values = url.Values{}
+	}
+	if value == "" {
+		values.Del(key)
+	} else {
+		values.Set(key, value)
+	}
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
+
+// link for a column header: sorts by field, or reverses it when it already is the first key
+func sortLink(current url.Values, keys []list.SortKey, field string) string {
+	next := list.SortKey{Field: field}
+	if len(keys) > 0 && keys[0] == next {
+		next.Desc = true
+	}
+	return relink("/list", current, "sort", next.String())
+}
+
+// arrow shown next to the header of the first sort key
+func sortMark(keys []list.SortKey, field string) string {
+	if len(keys) == 0 || keys[0].Field != field {
+		return ""
+	}
+	if keys[0].Desc {
+		return " ▼"
+	}
+	return " ▲"
+}
+
+// reads ?sort= (e.g. "priority,-created") and sorts items in place
+func sortItems(w http.ResponseWriter, r *http.Request, items []list.Item) ([]list.SortKey, bool) {
+	keys, err := list.ParseSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	list.Sort(items, keys)
+	return keys, true
+}
+
 func HandleAbout(w http.ResponseWriter, r *http.Request) {
 	path := resolvePath("web/about.html")
 	http.ServeFile(w, r, path)
 }
 
-func HandleListPage(w http.ResponseWriter, r *http.Request) {
-	items := list.LoadFromFile(list.DefaultDataFile)
+func (h *Handler) HandleListPage(w http.ResponseWriter, r *http.Request) {
+	h.renderListPage(w, r, http.StatusOK, nil)
+}
+
+// POST /list/status, the status form of a row. A rejected change is shown above the
+// table, listing the statuses the item can move to instead.
+func (h *Handler) HandleListStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
+		return
+	}
+	if _, err := h.actor(r).UpdateStatus(r.Context(), id, r.FormValue("status")); err != nil {
+		slog.Warn("List page status change failed", "id", id, "error", err, "trace_id", GetTraceID(r.Context()))
+		h.renderListPage(w, r, errorStatus(err), err)
+		return
+	}
+	name, _ := list.NormalizeListName(listName(r))
+	http.Redirect(w, r, pageLink("/list", name), http.StatusSeeOther)
+}
+
+// renders the list page, with statusErr (if any) shown above the table
+func (h *Handler) renderListPage(w http.ResponseWriter, r *http.Request, status int, statusErr error) {
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		slog.Error("Load items error", "error", err)
+		return
+	}
+
+	//the cloud counts the whole list, the table only what the filter selects
+	cloud := list.TagCounts(items)
+	filters := r.URL.Query()["tag"]
+	items, ok := filterTags(w, r, items)
+	if !ok {
+		return
+	}
+	//a bad query is shown next to the search box instead of failing the page
+	q := r.URL.Query().Get("q")
+	var queryErr *query.ParseError
+	if node, err := query.Parse(q); errors.As(err, &queryErr) {
+		slog.Warn("Invalid list page query", "q", q, "error", err)
+	} else {
+		items = query.Filter(items, node)
+	}
+	keys, ok := sortItems(w, r, items)
+	if !ok {
+		return
+	}
 
 	tmplpath := resolvePath("web/list.html")
-	tmpl, err := template.ParseFiles(tmplpath)
+	tmpl, err := template.New("list.html").Funcs(templateFuncs).ParseFiles(tmplpath)
 	if err != nil {
-		http.Error(w, "Error loading template", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, "Error loading template")
 		slog.Error("Template parse error", "error", err)
 		return
 	}
 
+	//the list selector, the one being shown is always there even when discovery fails
+	name, _ := list.NormalizeListName(listName(r))
+	names, err := h.lists(r).Names()
+	if err != nil {
+		slog.Warn("Could not find lists", "error", err)
+	}
+	if !slices.Contains(names, name) {
+		names = append(names, name)
+	}
+
 	data := struct {
-		Items []list.Item
+		List  string
+		Lists []string
+		Items []list.TreeNode
 		Count int
+		Sort  []list.SortKey
+		Tags  []list.TagCount
+		Tag   []string
+		Query string
+		// the query of the page, kept by its sort and tag links
+		Params url.Values
+		// set when Query does not parse
+		QueryError *query.ParseError
+		// set when a status change from the page was rejected
+		StatusError error
 	}{
-		Items: items,
-		Count: len(items),
+		List:        name,
+		Lists:       names,
+		Items:       list.Tree(items),
+		Count:       len(items),
+		Sort:        keys,
+		Tags:        cloud,
+		Tag:         filters,
+		Query:       q,
+		Params:      r.URL.Query(),
+		QueryError:  queryErr,
+		StatusError: statusErr,
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=u-8")
+	w.WriteHeader(status)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Error rendering page", http.StatusInternalServerError)
 		slog.Error("Template execution error", "error", err)
 	}
 }
 
-func HandleCreate(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	description := r.URL.Query().Get("description")
+	//accepts both ?description= and form bodies (load_tester.go posts forms)
+	description := r.FormValue("description")
 	if description == "" {
-		http.Error(w, "Missing description parameter", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "Missing description parameter", FieldError{Field: "description", Message: "required"})
 		return
 	}
 
-	items := list.LoadFromFile(list.DefaultDataFile)
-	items = list.Add(items, description)
-	list.SaveToFile(list.DefaultDataFile, items)
+	items, err := h.actor(r).Add(r.Context(), description)
+	if err != nil {
+		slog.Error("Create item failed", "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
 
 	slog.Info("Item created via API", "description", description)
 	w.Header().Set("Content-Type", "application/json")
@@ -80,8 +329,12 @@ func HandleCreate(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Handling /post request", "trace_id", traceID)
 }
 
-func HandleGet(w http.ResponseWriter, r *http.Request) {
-	items := list.LoadFromFile(list.DefaultDataFile)
+func (h *Handler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
 
@@ -89,9 +342,9 @@ func HandleGet(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Handling /get request", "trace_id", traceID)
 }
 
-func HandleUpdate(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -100,59 +353,86 @@ func HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	value := r.URL.Query().Get("value")
 
 	if idStr == "" || field == "" || value == "" {
-		http.Error(w, "Missing required parameters (id, field, value)", http.StatusBadRequest)
+		var missing []FieldError
+		for _, param := range [][2]string{{"id", idStr}, {"field", field}, {"value", value}} {
+			if param[1] == "" {
+				missing = append(missing, FieldError{Field: param[0], Message: "required"})
+			}
+		}
+		writeProblem(w, r, http.StatusBadRequest, "Missing required parameters (id, field, value)", missing...)
 		return
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
 		return
 	}
 
-	items := list.LoadFromFile(list.DefaultDataFile)
+	var items []list.Item
 
 	switch field {
 	case "description":
-		items, err = list.UpdateDescription(items, id, value)
+		items, err = h.actor(r).UpdateDescription(r.Context(), id, value)
 	case "status":
-		items, err = list.UpdateStatus(items, id, value)
+		//force=true starts or completes an item whose blockers are still open
+		force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+		items, err = h.actor(r).Patch(r.Context(), id, list.ItemPatch{Status: &value, Force: force})
+	case "notes":
+		items, err = h.actor(r).Patch(r.Context(), id, list.ItemPatch{Notes: &value})
+	case "due":
+		items, err = h.actor(r).Patch(r.Context(), id, list.ItemPatch{Due: &value})
+	case "priority":
+		items, err = h.actor(r).Patch(r.Context(), id, list.ItemPatch{Priority: &value})
+	case "repeat":
+		items, err = h.actor(r).Patch(r.Context(), id, list.ItemPatch{Repeat: &value})
 	default:
-		http.Error(w, "Invalid field(must be 'description' or 'status')", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid field(must be 'description', 'status', 'notes', 'due', 'priority' or 'repeat')",
+			FieldError{Field: "field", Message: "must be 'description', 'status', 'notes', 'due', 'priority' or 'repeat'"})
 		return
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		slog.Error("Update item failed", "id", id, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
 		return
 	}
 
-	list.SaveToFile(list.DefaultDataFile, items)
 	slog.Info("Item updated via API", "id", id, "field", field, "value", value)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
 
 	traceID := GetTraceID(r.Context())
 	slog.Info("Handling /put request", "trace_id", traceID)
-
 }
 
-func HandleDelete(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeProblem(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid ID", FieldError{Field: "id", Message: "must be an integer"})
 		return
 	}
 
-	items := list.LoadFromFile(list.DefaultDataFile)
-	items = list.Delete(items, id)
-	list.SaveToFile(list.DefaultDataFile, items)
+	mode, ok := deleteMode(w, r)
+	if !ok {
+		return
+	}
+	items, err := h.actor(r).Delete(r.Context(), id, mode)
+	//the legacy route always treated deleting a missing item as success
+	if errors.Is(err, list.ErrNotFound) {
+		err = nil
+	}
+	if err != nil {
+		slog.Error("Delete item failed", "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
 
 	slog.Info("Item deleted via API", "id", id)
 	w.Header().Set("Content-Type", "application/json")
@@ -162,20 +442,20 @@ func HandleDelete(w http.ResponseWriter, r *http.Request) {
 	slog.Info("Handling /delete request", "trace_id", traceID)
 }
 
-func StartServer() {
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/create", HandleCreate)
-	mux.HandleFunc("/get", HandleGet)
-	mux.HandleFunc("/update", HandleUpdate)
-	mux.HandleFunc("/delete", HandleDelete)
-
-	//web routes
-	mux.HandleFunc("/about", HandleAbout)
-	mux.HandleFunc("/list", HandleListPage)
-
-	handler := TraceMiddleware(mux)
+// serves the lists of every user on :8080, behind API keys unless keys is nil
+func StartServer(users *list.Users, requireUser, trustUserHeader bool, keys *auth.Keys) {
+	h := NewUsersHandler(users)
+	h.RequireUser = requireUser
+	h.TrustUserHeader = trustUserHeader
+	var handler http.Handler = h.Routes()
+	//without keys the API is open, as it was before API keys
+	if keys != nil {
+		handler = AuthMiddleware(keys, handler)
+	}
+	handler = TraceMiddleware(handler)
 
-	slog.Info("Starting HTTP server", "port", 8080)
-	http.ListenAndServe(":8080", handler)
+	slog.Info("Starting HTTP server", "port", 8080, "auth", keys != nil)
+	if err := http.ListenAndServe(":8080", handler); err != nil {
+		slog.Error("HTTP server stopped", "error", err)
+	}
 }
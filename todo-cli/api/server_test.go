@@ -2,23 +2,34 @@ package api_test
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"todo-cli/api"
+	"todo-cli/list"
 )
 
-func getMux() *http.ServeMux {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/create", api.HandleCreate)
-	mux.HandleFunc("/get", api.HandleGet)
-	mux.HandleFunc("/update", api.HandleUpdate)
-	mux.HandleFunc("/delete", api.HandleDelete)
-	return mux
+// every mux gets its own actor and in-memory store so tests never touch items.json
+func newTestHandler(t *testing.T, store list.Store) *api.Handler {
+	actor, err := list.NewPersistentListActor(store, 0)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	t.Cleanup(actor.Stop)
+	return api.NewHandler(actor)
+}
+
+func getMux(t *testing.T) *http.ServeMux {
+	return newTestHandler(t, list.NewMemoryStore(nil)).Routes()
 }
 
 func TestCreateItem(t *testing.T) {
-	mux := getMux()
+	mux := getMux(t)
 	req := httptest.NewRequest(http.MethodPost, "/create?description=TestTask", nil)
 	w := httptest.NewRecorder()
 
@@ -40,7 +51,7 @@ func TestCreateItem(t *testing.T) {
 }
 
 func TestGetItems(t *testing.T) {
-	mux := getMux()
+	mux := getMux(t)
 	req := httptest.NewRequest(http.MethodGet, "/get", nil)
 	w := httptest.NewRecorder()
 
@@ -57,19 +68,19 @@ func TestGetItems(t *testing.T) {
 }
 
 func TestUpdateInvalidItem(t *testing.T) {
-	mux := getMux()
+	mux := getMux(t)
 	req := httptest.NewRequest(http.MethodPut, "/update?id=999&field=description&value=Nope", nil)
 	w := httptest.NewRecorder()
 
 	mux.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Fatalf("Expected 400 Bad Request, got %d", w.Code)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404 Not Found, got %d", w.Code)
 	}
 }
 
 func TestDeleteInvalidItem(t *testing.T) {
-	mux := getMux()
+	mux := getMux(t)
 	req := httptest.NewRequest(http.MethodDelete, "/delete?id=999", nil)
 	w := httptest.NewRecorder()
 
@@ -79,3 +90,86 @@ func TestDeleteInvalidItem(t *testing.T) {
 		t.Fatalf("Expected 200 OK, got %d", w.Code)
 	}
 }
+
+// loads fine but every save fails like a full disk
+type failingStore struct {
+	*list.MemoryStore
+}
+
+func (failingStore) Apply(changes []list.Change) ([]list.Item, []list.Redone, error) {
+	return nil, nil, &list.WriteError{File: "items.json", Err: errors.New("disk full")}
+}
+
+// a store that cannot write must not report success
+func TestCreateItem_SaveFailure(t *testing.T) {
+	mux := newTestHandler(t, failingStore{list.NewMemoryStore(nil)}).Routes()
+
+	req := httptest.NewRequest(http.MethodPost, "/create?description=TestTask", nil)
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500 Internal Server Error, got %d", w.Code)
+	}
+	if w.Body.Len() == 0 {
+		t.Errorf("Expected an error body")
+	}
+}
+
+// load_tester.go posts the description as a form body
+func TestCreateItem_FormBody(t *testing.T) {
+	mux := getMux(t)
+	form := url.Values{"description": {"Form Task"}}
+	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+}
+
+// the same traffic as load_tester.go must produce one unique item per request, in memory and on disk
+func TestCreateItem_ConcurrentRequests(t *testing.T) {
+	const total = 500
+	store := list.NewMemoryStore(nil)
+	mux := newTestHandler(t, store).Routes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/create?description=load+task+%d", n), nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected 200 OK, got %d", w.Code)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/get", nil))
+	var items []list.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	ids := map[int]bool{}
+	for _, item := range items {
+		ids[item.ID] = true
+	}
+	if len(ids) != total {
+		t.Errorf("Expected %d unique items, got %d", total, len(ids))
+	}
+
+	saved, _ := store.Load()
+	if len(saved) != total {
+		t.Errorf("Expected %d saved items, got %d", total, len(saved))
+	}
+}
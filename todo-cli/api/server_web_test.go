@@ -4,21 +4,26 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"todo-cli/api"
+	"todo-cli/list"
 )
 
-func getTestMux() *http.ServeMux {
+func getTestMux(t *testing.T) *http.ServeMux {
+	actor := list.NewListActor(nil)
+	t.Cleanup(actor.Stop)
+	h := api.NewHandler(actor)
 	mux := http.NewServeMux()
 	mux.HandleFunc("/about", api.HandleAbout)
-	mux.HandleFunc("/list", api.HandleListPage)
+	mux.HandleFunc("/list", h.HandleListPage)
 	return mux
 }
 
 // test the /about endpoint (static html)
 func TestAboutPage(t *testing.T) {
-	mux := getTestMux()
+	mux := getTestMux(t)
 	req := httptest.NewRequest(http.MethodGet, "/about", nil)
 	w := httptest.NewRecorder()
 
@@ -38,7 +43,7 @@ func TestAboutPage(t *testing.T) {
 
 // test the /list endpoint (dynamic html)
 func TestListPage(t *testing.T) {
-	mux := getTestMux()
+	mux := getTestMux(t)
 	req := httptest.NewRequest(http.MethodGet, "/list", nil)
 	w := httptest.NewRecorder()
 
@@ -60,3 +65,173 @@ func TestListPage(t *testing.T) {
 		t.Errorf("Expected to see either a table or a empty message, got \n%s", html)
 	}
 }
+
+// column headers link to the sort they apply, clicking the current one reverses it
+func TestListPage_Sort(t *testing.T) {
+	actor := list.NewListActor([]list.Item{
+		{ID: 1, Description: "Later", Status: list.StatusNotStarted, Priority: list.PriorityP2},
+		{ID: 2, Description: "Sooner", Status: list.StatusNotStarted, Priority: list.PriorityP0},
+	})
+	t.Cleanup(actor.Stop)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", api.NewHandler(actor).HandleListPage)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?sort=priority", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	html := w.Body.String()
+
+	if strings.Index(html, "Sooner") > strings.Index(html, "Later") {
+		t.Errorf("Expected the P0 item first, got \n%s", html)
+	}
+	if !strings.Contains(html, `href="/list?sort=-priority"`) || !strings.Contains(html, `href="/list?sort=created"`) {
+		t.Errorf("Expected sort links in the headers, got \n%s", html)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/list?sort=bogus", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown sort, got %d", w.Code)
+	}
+}
+
+func TestListPage_TagCloud(t *testing.T) {
+	actor := list.NewListActor([]list.Item{
+		{ID: 1, Description: "API", Status: list.StatusNotStarted, Tags: []string{"backend"}},
+		{ID: 2, Description: "Schema", Status: list.StatusNotStarted, Tags: []string{"backend", "db"}},
+		{ID: 3, Description: "Styles", Status: list.StatusNotStarted, Tags: []string{"frontend"}},
+	})
+	t.Cleanup(actor.Stop)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", api.NewHandler(actor).HandleListPage)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?tag=backend", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	html := w.Body.String()
+
+	if !strings.Contains(html, `class="active">backend (2)`) || !strings.Contains(html, "frontend (1)") {
+		t.Errorf("Expected the tag cloud with counts, got \n%s", html)
+	}
+	if strings.Contains(html, "Styles") {
+		t.Errorf("Expected the table to be filtered by tag, got \n%s", html)
+	}
+
+	//sorting keeps the filters, picking a tag keeps the search and the sort
+	req = httptest.NewRequest(http.MethodGet, "/list?tag=backend&q=schema&sort=id", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	html = w.Body.String()
+	if !strings.Contains(html, `href="/list?q=schema&amp;sort=priority&amp;tag=backend"`) {
+		t.Errorf("Expected sort links keeping tag and q, got \n%s", html)
+	}
+	if !strings.Contains(html, `href="/list?q=schema&amp;sort=id&amp;tag=db"`) || !strings.Contains(html, `href="/list?q=schema&amp;sort=id">clear filter`) {
+		t.Errorf("Expected tag links keeping q and sort, got \n%s", html)
+	}
+}
+
+func TestListPage_Search(t *testing.T) {
+	actor := list.NewListActor([]list.Item{
+		{ID: 1, Description: "Fix login bug", Status: list.StatusStarted},
+		{ID: 2, Description: "Write docs", Status: list.StatusNotStarted},
+	})
+	t.Cleanup(actor.Stop)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", api.NewHandler(actor).HandleListPage)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?q=status%3Astarted", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	html := w.Body.String()
+	if !strings.Contains(html, "Fix login bug") || strings.Contains(html, "Write docs") {
+		t.Errorf("Expected only the started item, got \n%s", html)
+	}
+	if !strings.Contains(html, `name="q" value="status:started"`) {
+		t.Errorf("Expected the search box to keep the query, got \n%s", html)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/list?q=colour%3Ared", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "unknown field &#34;colour&#34; at position 1") {
+		t.Errorf("Expected the parse error on the page, got %d \n%s", w.Code, w.Body.String())
+	}
+}
+
+func TestListPage_Subtasks(t *testing.T) {
+	zero := 0
+	actor := list.NewListActor([]list.Item{
+		{ID: 0, Description: "Launch", Status: list.StatusNotStarted},
+		{ID: 1, Description: "Design", Status: list.StatusCompleted, ParentID: &zero},
+		{ID: 2, Description: "Build", Status: list.StatusNotStarted, ParentID: &zero},
+	})
+	t.Cleanup(actor.Stop)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", api.NewHandler(actor).HandleListPage)
+
+	req := httptest.NewRequest(http.MethodGet, "/list", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	html := w.Body.String()
+
+	if !strings.Contains(html, "Launch <span class=\"progress\">(1/2 done)</span>") {
+		t.Errorf("Expected the roll-up on the parent, got \n%s", html)
+	}
+	if !strings.Contains(html, `style="padding-left: 32px">└─ Design`) {
+		t.Errorf("Expected the subtask to be indented, got \n%s", html)
+	}
+}
+
+func TestListPage_StatusForm(t *testing.T) {
+	wf, err := list.LoadWorkflow("../workflow.example.json")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	t.Cleanup(list.SetWorkflow(wf))
+	mux := getMux(t)
+	serve(mux, http.MethodPost, "/items", `{"description": "Ship it"}`)
+
+	//only the moves the workflow allows are offered
+	html := serve(mux, http.MethodGet, "/list", "").Body.String()
+	if !strings.Contains(html, "<option>started</option><option>cancelled</option></select>") {
+		t.Errorf("Expected the status form to offer started and cancelled, got: \n%s", html)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/list/status", strings.NewReader(url.Values{"id": {"0"}, "status": {"completed"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "valid next statuses: &#34;started&#34;, &#34;cancelled&#34;") {
+		t.Errorf("Expected the rejected move on the page, got %d: \n%s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/list/status", strings.NewReader("id=0&status=started"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/list" {
+		t.Errorf("Expected a redirect back to the list, got %d", w.Code)
+	}
+}
+
+func TestListPage_Lists(t *testing.T) {
+	lists := list.NewLists(func(string) (list.Store, error) { return list.NewMemoryStore(nil), nil }, 0)
+	t.Cleanup(lists.Stop)
+	mux := api.NewListsHandler(lists).Routes()
+	serve(mux, http.MethodPost, "/items", `{"description": "Groceries"}`)
+	serve(mux, http.MethodPost, "/lists/work/items", `{"description": "Standup"}`)
+	serve(mux, http.MethodPatch, "/lists/work/items/0", `{"add_tags": ["team"]}`)
+
+	html := serve(mux, http.MethodGet, "/list?list=work", "").Body.String()
+	if !strings.Contains(html, "<option>default</option><option selected>work</option>") {
+		t.Errorf("Expected the list selector with work selected, got: \n%s", html)
+	}
+	if !strings.Contains(html, "Standup") || strings.Contains(html, "Groceries") {
+		t.Errorf("Expected only the items of work, got: \n%s", html)
+	}
+	//tag and sort links stay on the list
+	if !strings.Contains(html, `href="/list?list=work&amp;tag=team"`) || !strings.Contains(html, `href="/list?list=work&amp;sort=priority"`) {
+		t.Errorf("Expected links keeping the list, got: \n%s", html)
+	}
+}
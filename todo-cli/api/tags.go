@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"todo-cli/list"
+)
+
+func (h *Handler) registerTagRoutes(mux *http.ServeMux, prefix string) {
+	h.handle(mux, "GET "+prefix+"/tags", h.HandleListTags)
+	h.handle(mux, "POST "+prefix+"/tags/merge", h.HandleMergeTags)
+	h.handle(mux, "POST "+prefix+"/tags/{tag}/rename", h.HandleRenameTag)
+}
+
+// applies ?tag= filters (repeatable, "!tag" excludes) and writes a problem for bad ones
+func filterTags(w http.ResponseWriter, r *http.Request, items []list.Item) ([]list.Item, bool) {
+	filters := r.URL.Query()["tag"]
+	if len(filters) == 0 {
+		return items, true
+	}
+	items, err := list.FilterTags(items, filters)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return items, true
+}
+
+// GET /tags, every tag in use with the number of items carrying it
+func (h *Handler) HandleListTags(w http.ResponseWriter, r *http.Request) {
+	items, err := h.actor(r).GetAll(r.Context())
+	if err != nil {
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list.TagCounts(items))
+}
+
+// POST /tags/merge {"from": ["be", "server"], "into": "backend"}
+func (h *Handler) HandleMergeTags(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		From []string `json:"from"`
+		Into string   `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if len(body.From) == 0 || body.Into == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Missing from or into",
+			FieldError{Field: "from", Message: "at least one tag required"}, FieldError{Field: "into", Message: "required"})
+		return
+	}
+	h.mergeTags(w, r, body.Into, body.From...)
+}
+
+// POST /tags/{tag}/rename {"to": "backend"}
+func (h *Handler) HandleRenameTag(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		To string `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if body.To == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Missing to", FieldError{Field: "to", Message: "required"})
+		return
+	}
+	h.mergeTags(w, r, body.To, r.PathValue("tag"))
+}
+
+func (h *Handler) mergeTags(w http.ResponseWriter, r *http.Request, into string, from ...string) {
+	items, err := h.actor(r).MergeTags(r.Context(), into, from...)
+	if err != nil {
+		slog.Error("Merge tags failed", "from", from, "into", into, "error", err, "trace_id", GetTraceID(r.Context()))
+		writeError(w, r, errorStatus(err), err)
+		return
+	}
+	slog.Info("Tags merged via API", "from", from, "into", into, "trace_id", GetTraceID(r.Context()))
+	writeJSON(w, http.StatusOK, list.TagCounts(items))
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"todo-cli/list"
+)
+
+// the header naming the user a request acts for
+const UserHeader = "X-User"
+
+type scopeKey struct{}
+
+// what a request may touch: the lists of its user and the actor of the list it names
+type scope struct {
+	lists *list.Lists
+	actor *list.ListActor
+}
+
+// the user of the request: the one its API key acts for, else the one in its X-User
+// header when the handler trusts it, "" when it names none. ok is false for a header
+// the handler doesn't trust.
+func (h *Handler) requestUser(r *http.Request) (user string, ok bool) {
+	if user := authUser(r.Context()); user != "" {
+		return user, true
+	}
+	user = strings.TrimSpace(r.Header.Get(UserHeader))
+	return user, user == "" || h.TrustUserHeader
+}
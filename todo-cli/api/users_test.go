@@ -0,0 +1,144 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo-cli/api"
+	"todo-cli/list"
+)
+
+// a handler for many users that trusts X-User, as it would behind an authenticating proxy
+func usersHandler(t *testing.T) *api.Handler {
+	users := list.NewUsers(func(string) (*list.Lists, error) {
+		return list.NewLists(func(string) (list.Store, error) { return list.NewMemoryStore(nil), nil }, 0), nil
+	})
+	t.Cleanup(users.Stop)
+	h := api.NewUsersHandler(users)
+	h.TrustUserHeader = true
+	return h
+}
+
+// like serve, acting for user
+func serveAs(mux http.Handler, user, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(api.UserHeader, user)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestUsers_Isolation(t *testing.T) {
+	mux := usersHandler(t).Routes()
+	serveAs(mux, "alice", http.MethodPost, "/items", `{"description": "Alice's secret"}`)
+	serveAs(mux, "alice", http.MethodPost, "/lists/work/items", `{"description": "Alice's work"}`)
+	//bob exists, but has nothing in the lists alice uses
+	serveAs(mux, "bob", http.MethodPost, "/lists/home/items", `{"description": "Bob's home"}`)
+
+	//bob has no items, every ID he guesses is not found
+	requests := []struct{ method, target, body string }{
+		{http.MethodGet, "/items/0", ""},
+		{http.MethodPatch, "/items/0", `{"description": "Owned"}`},
+		{http.MethodDelete, "/items/0", ""},
+		{http.MethodGet, "/lists/work/items/0", ""},
+		{http.MethodDelete, "/lists/work/items/0", ""},
+		{http.MethodPost, "/items/0/move", `{"list": "work"}`},
+		{http.MethodPost, "/items/0/dependencies", `{"blocked_by": 0}`},
+		{http.MethodPut, "/update?id=0&field=status&value=completed", ""},
+	}
+	for _, req := range requests {
+		if w := serveAs(mux, "bob", req.method, req.target, req.body); w.Code != http.StatusNotFound && w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s as bob: expected not found, got %d %s", req.method, req.target, w.Code, w.Body.String())
+		}
+	}
+	//the legacy route treats a missing item as deleted, it only ever sees bob's list
+	if w := serveAs(mux, "bob", http.MethodDelete, "/delete?id=0", ""); w.Body.String() != "[]\n" {
+		t.Errorf("DELETE /delete?id=0 as bob: expected bob's empty list, got %s", w.Body.String())
+	}
+	for _, target := range []string{"/items", "/get", "/lists/work/items", "/items/search?q=secret", "/list"} {
+		if w := serveAs(mux, "bob", http.MethodGet, target, ""); strings.Contains(w.Body.String(), "Alice") {
+			t.Errorf("GET %s as bob: expected none of alice's items, got %s", target, w.Body.String())
+		}
+	}
+
+	//bob's own items get their own IDs and leave alice's untouched
+	w := serveAs(mux, "bob", http.MethodPost, "/items", `{"description": "Bob's"}`)
+	var created list.Item
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID != 0 {
+		t.Errorf("Expected bob's first item to be 0, got %d", created.ID)
+	}
+	serveAs(mux, "bob", http.MethodDelete, "/items/0", "")
+
+	w = serveAs(mux, "alice", http.MethodGet, "/items/0", "")
+	var item list.Item
+	json.Unmarshal(w.Body.Bytes(), &item)
+	if w.Code != http.StatusOK || item.Description != "Alice's secret" {
+		t.Errorf("Expected alice's item unchanged, got %d %+v", w.Code, item)
+	}
+	w = serveAs(mux, "alice", http.MethodGet, "/lists", "")
+	if !strings.Contains(w.Body.String(), `[{"name":"default","count":1},{"name":"work","count":1}]`) {
+		t.Errorf("Expected alice's work list intact, got %s", w.Body.String())
+	}
+}
+
+func TestUsers_Identity(t *testing.T) {
+	h := usersHandler(t)
+	h.RequireUser = true
+	mux := h.Routes()
+
+	w := serve(mux, http.MethodGet, "/items", "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"type":"/problems/unauthorized"`) {
+		t.Errorf("Expected 401 without a user, got %d %s", w.Code, w.Body.String())
+	}
+	w = serveAs(mux, "../alice", http.MethodGet, "/items", "")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"user"`) {
+		t.Errorf("Expected 400 for an invalid user name, got %d %s", w.Code, w.Body.String())
+	}
+
+	//a user without data reads an empty default list, and reading creates nobody
+	for target, want := range map[string]string{"/items": "[]", "/lists": `[{"name":"default","count":0}]`} {
+		w := serveAs(mux, "carol", http.MethodGet, target, "")
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+			t.Errorf("GET %s as a new user: expected 200 %s, got %d %s", target, want, w.Code, w.Body.String())
+		}
+	}
+	if w := serveAs(mux, "carol", http.MethodGet, "/lists/work/items", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a list the new user doesn't have, got %d", w.Code)
+	}
+	if w := serveAs(mux, "carol", http.MethodDelete, "/items/0", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting as a new user, got %d", w.Code)
+	}
+	serveAs(mux, "carol", http.MethodPost, "/items", `{"description": "Carol's"}`)
+	if w := serveAs(mux, "carol", http.MethodGet, "/items", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Carol's") {
+		t.Errorf("Expected the first write to create carol, got %d %s", w.Code, w.Body.String())
+	}
+
+	//a single user server serves nobody else
+	single := newTestHandler(t, list.NewMemoryStore(nil))
+	single.TrustUserHeader = true
+	if w := serveAs(single.Routes(), "alice", http.MethodGet, "/items", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", w.Code)
+	}
+}
+
+// without API keys the X-User header is only taken when the server is told to trust it
+func TestUsers_UntrustedHeader(t *testing.T) {
+	mux := getMux(t)
+	w := serveAs(mux, "alice", http.MethodGet, "/items", "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"type":"/problems/unauthorized"`) {
+		t.Errorf("Expected 401 for an untrusted X-User header, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serveAs(mux, "alice", http.MethodPost, "/items", `{"description": "Impersonated"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 writing with an untrusted X-User header, got %d", w.Code)
+	}
+	//requests naming no user are still served as the default user
+	if w := serve(mux, http.MethodGet, "/items", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 without a user, got %d", w.Code)
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"todo-cli/list"
+)
+
+// GET /workflow, the statuses items can be in and where each one can move next
+func HandleWorkflow(w http.ResponseWriter, r *http.Request) {
+	wf := list.CurrentWorkflow()
+	next := make(map[string][]string, len(wf.Statuses))
+	for _, status := range wf.Statuses {
+		next[status] = wf.Next(status)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		list.Workflow
+		// every status with the statuses it can move to, spelled out for clients
+		Next map[string][]string `json:"next"`
+	}{wf, next})
+}
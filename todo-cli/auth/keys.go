@@ -0,0 +1,291 @@
+// Package auth manages the API keys of the HTTP API. Keys are kept in a local JSON
+// file as salted SHA-256 hashes, the key itself is only shown once when it is created.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"todo-cli/list"
+)
+
+// where keys are kept unless -keys names another file
+const DefaultKeysFile = "keys.json"
+
+// every key starts with it, so a leaked key is easy to recognise
+const tokenPrefix = "todo_"
+
+var (
+	// a key that is malformed, unknown, revoked or doesn't match its hash
+	ErrInvalidKey = errors.New("invalid API key")
+	// revoking a key ID that isn't in the file
+	ErrKeyNotFound = errors.New("API key not found")
+	// a scope other than read, write or admin
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// what a key may do. Each scope includes the ones before it: write can also read and
+// admin can also write and act for any user.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+var scopes = []Scope{ScopeRead, ScopeWrite, ScopeAdmin}
+
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(scopes, scope) {
+		return "", fmt.Errorf("%w: %q, use read, write or admin", ErrInvalidScope, s)
+	}
+	return scope, nil
+}
+
+// reports whether a key with scope s may do what needs the scope need
+func (s Scope) Allows(need Scope) bool {
+	return slices.Index(scopes, s) >= slices.Index(scopes, need)
+}
+
+// an API key as stored in the keys file, without the secret part of the key
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	User      string     `json:"user"`
+	Scope     Scope      `json:"scope"`
+	Salt      string     `json:"salt"`
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Keys is the keys file. Verify reloads it when another process changed it, so a key
+// revoked from the command line stops working in a running server.
+type Keys struct {
+	file string
+	mu   sync.Mutex
+	keys []Key
+	// the file as last read, nil when there was none
+	info os.FileInfo
+}
+
+// reads the keys file, a missing file is no keys yet
+func LoadKeys(file string) (*Keys, error) {
+	k := &Keys{file: file}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func (k *Keys) load() error {
+	info, err := os.Stat(k.file)
+	if errors.Is(err, os.ErrNotExist) {
+		k.keys, k.info = nil, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(k.file)
+	if err != nil {
+		return err
+	}
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("reading %s: %w", k.file, err)
+	}
+	k.keys, k.info = keys, info
+	return nil
+}
+
+// reloads the file when it changed since it was last read. Every save renames a new
+// file into place, so comparing the file itself catches changes within one mtime tick.
+func (k *Keys) refresh() error {
+	info, err := os.Stat(k.file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if k.info == nil {
+			return nil
+		}
+	case err != nil:
+		return err
+	case k.info != nil && os.SameFile(info, k.info) && info.ModTime().Equal(k.info.ModTime()):
+		return nil
+	}
+	return k.load()
+}
+
+// writes the keys readable by the owner only, atomically so a crash never leaves half a
+// file behind. Callers hold the keys file lock.
+func (k *Keys) save(keys []Key) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	//no backup generation, it would still list revoked keys as active
+	if err := list.ReplaceFileAtomic(k.file, data, 0600); err != nil {
+		return err
+	}
+	//and drop the one earlier versions left behind
+	if err := os.Remove(k.file + ".bak"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	info, err := os.Stat(k.file)
+	if err != nil {
+		return err
+	}
+	k.keys, k.info = keys, info
+	return nil
+}
+
+// runs fn with the keys file locked against other processes and freshly read, so a
+// change made by fn is never saved over one another process just made
+func (k *Keys) update(fn func() error) error {
+	return list.WithFileLock(k.file, true, list.DefaultLockTimeout, func() error {
+		if err := k.refresh(); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// creates a key acting for user with scope and saves its hash. The returned token is
+// the key itself, it can't be recovered later.
+func (k *Keys) Create(name, user string, scope Scope) (token string, key Key, err error) {
+	if scope, err = ParseScope(string(scope)); err != nil {
+		return "", Key{}, err
+	}
+	if user, err = list.NormalizeUserName(user); err != nil {
+		return "", Key{}, err
+	}
+	id, err := randomHex(6)
+	if err != nil {
+		return "", Key{}, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", Key{}, err
+	}
+	salt, err := randomHex(16)
+	if err != nil {
+		return "", Key{}, err
+	}
+	key = Key{
+		ID:        id,
+		Name:      strings.TrimSpace(name),
+		User:      user,
+		Scope:     scope,
+		Salt:      salt,
+		Hash:      hashSecret(salt, secret),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	err = k.update(func() error {
+		return k.save(append(slices.Clone(k.keys), key))
+	})
+	if err != nil {
+		return "", Key{}, err
+	}
+	slog.Info("API key created", "id", key.ID, "name", key.Name, "user", key.User, "scope", key.Scope)
+	return tokenPrefix + id + "_" + secret, key, nil
+}
+
+// every key in the file, revoked ones included, oldest first
+func (k *Keys) List() ([]Key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.refresh(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(k.keys), nil
+}
+
+// revokes the key with id, it is kept in the file so List still shows it
+func (k *Keys) Revoke(id string) (Key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	var revoked Key
+	err := k.update(func() error {
+		i := slices.IndexFunc(k.keys, func(key Key) bool { return key.ID == id })
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+		}
+		if k.keys[i].Revoked() {
+			revoked = k.keys[i]
+			return nil
+		}
+		keys := slices.Clone(k.keys)
+		now := time.Now().UTC()
+		keys[i].RevokedAt = &now
+		if err := k.save(keys); err != nil {
+			return err
+		}
+		revoked = keys[i]
+		slog.Info("API key revoked", "id", id, "name", revoked.Name)
+		return nil
+	})
+	if err != nil {
+		return Key{}, err
+	}
+	return revoked, nil
+}
+
+// the key a token belongs to, ErrInvalidKey unless it is a known key that isn't revoked
+func (k *Keys) Verify(token string) (Key, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, tokenPrefix), "_")
+	if !ok || !strings.HasPrefix(token, tokenPrefix) {
+		return Key{}, fmt.Errorf("%w: not a todo API key", ErrInvalidKey)
+	}
+
+	k.mu.Lock()
+	if err := k.refresh(); err != nil {
+		slog.Warn("Could not reload API keys, using the ones already loaded", "file", k.file, "error", err)
+	}
+	i := slices.IndexFunc(k.keys, func(key Key) bool { return key.ID == id })
+	var key Key
+	if i >= 0 {
+		key = k.keys[i]
+	}
+	k.mu.Unlock()
+
+	if i < 0 || subtle.ConstantTimeCompare([]byte(hashSecret(key.Salt, secret)), []byte(key.Hash)) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	if key.Revoked() {
+		return Key{}, fmt.Errorf("%w: key %s was revoked", ErrInvalidKey, key.ID)
+	}
+	return key, nil
+}
+
+// hex SHA-256 of salt and secret
+func hashSecret(salt, secret string) string {
+	sum := sha256.Sum256([]byte(salt + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
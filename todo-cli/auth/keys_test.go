@@ -0,0 +1,117 @@
+package auth_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"todo-cli/auth"
+)
+
+func TestKeys_CreateVerifyRevoke(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := auth.LoadKeys(file)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	token, key, err := keys.Create("ci", "Alice", auth.ScopeWrite)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if key.User != "alice" || !strings.HasPrefix(token, "todo_"+key.ID+"_") {
+		t.Errorf("Unexpected key %+v for token %s", key, token)
+	}
+	got, err := keys.Verify(token)
+	if err != nil || got.ID != key.ID || got.Scope != auth.ScopeWrite {
+		t.Errorf("Expected the key back, got %+v (%v)", got, err)
+	}
+
+	//only a salted hash is stored
+	data, _ := os.ReadFile(file)
+	secret := token[strings.LastIndex(token, "_")+1:]
+	if strings.Contains(string(data), secret) || !strings.Contains(string(data), key.Salt) {
+		t.Errorf("Expected the file to hold the hash, not the key: %s", data)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the keys file readable by its owner only, got %v", info.Mode())
+	}
+
+	for _, bad := range []string{"", "todo_" + key.ID + "_wrong", token[:len(token)-1], "todo_nope_" + secret, secret} {
+		if _, err := keys.Verify(bad); !errors.Is(err, auth.ErrInvalidKey) {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", bad, err)
+		}
+	}
+
+	//another process revoking the key is picked up without a restart
+	other, _ := auth.LoadKeys(file)
+	os.WriteFile(file+".bak", data, 0600)
+	if _, err := other.Revoke(key.ID); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	//no backup lists the key as active any more, not even one left by earlier versions
+	if _, err := os.Stat(file + ".bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no backup of the keys file, got %v", err)
+	}
+	if _, err := keys.Verify(token); !errors.Is(err, auth.ErrInvalidKey) {
+		t.Errorf("Expected a revoked key to be invalid, got %v", err)
+	}
+	listed, _ := keys.List()
+	if len(listed) != 1 || !listed[0].Revoked() {
+		t.Errorf("Expected the revoked key to be listed, got %+v", listed)
+	}
+	if _, err := keys.Revoke("nope"); !errors.Is(err, auth.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// several processes creating keys at once each read, change and write the file under
+// its lock, so none of them saves over a key another one just added
+func TestKeys_ConcurrentCreatesKeepEveryKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.json")
+	const processes, perProcess = 4, 5
+
+	var wg sync.WaitGroup
+	for p := 0; p < processes; p++ {
+		keys, err := auth.LoadKeys(file)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProcess; i++ {
+				if _, _, err := keys.Create("ci", "alice", auth.ScopeRead); err != nil {
+					t.Errorf("Unexpected error %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	keys, _ := auth.LoadKeys(file)
+	if listed, _ := keys.List(); len(listed) != processes*perProcess {
+		t.Errorf("Expected %d keys, got %d", processes*perProcess, len(listed))
+	}
+	if _, err := os.Stat(file + ".bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected no backup of the keys file, got %v", err)
+	}
+}
+
+func TestKeys_Create_Invalid(t *testing.T) {
+	keys, _ := auth.LoadKeys(filepath.Join(t.TempDir(), "keys.json"))
+	if _, _, err := keys.Create("ci", "alice", "root"); !errors.Is(err, auth.ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope, got %v", err)
+	}
+	if _, _, err := keys.Create("ci", "../alice", auth.ScopeRead); err == nil {
+		t.Errorf("Expected an invalid user to be refused")
+	}
+}
+
+func TestScope_Allows(t *testing.T) {
+	if !auth.ScopeAdmin.Allows(auth.ScopeWrite) || !auth.ScopeWrite.Allows(auth.ScopeRead) || auth.ScopeRead.Allows(auth.ScopeWrite) {
+		t.Errorf("Expected every scope to include the ones below it only")
+	}
+}
@@ -1,8 +1,14 @@
 package list
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"time"
+	"todo-cli/trace"
 )
 
 var ErrActorStopped = errors.New("list actor has been stopped")
@@ -14,23 +20,100 @@ const (
 	cmdUpdateDesc
 	cmdUpdateStatus
 	cmdDelete
+	cmdPatch
+	cmdMergeTags
+	cmdImport
+	cmdSearch
 	cmdGetAll
+	cmdSubscribe
+	cmdUnsubscribe
 )
 
+func (c commandType) String() string {
+	switch c {
+	case cmdAdd:
+		return "add"
+	case cmdUpdateDesc:
+		return "update_description"
+	case cmdUpdateStatus:
+		return "update_status"
+	case cmdDelete:
+		return "delete"
+	case cmdPatch:
+		return "patch"
+	case cmdMergeTags:
+		return "merge_tags"
+	case cmdImport:
+		return "import"
+	case cmdSearch:
+		return "search"
+	case cmdGetAll:
+		return "get_all"
+	case cmdSubscribe:
+		return "subscribe"
+	case cmdUnsubscribe:
+		return "unsubscribe"
+	}
+	return "unknown"
+}
+
 type command struct {
+	ctx     context.Context
 	cmdType commandType
 	id      int
 	value   string
-	replyCh chan []Item
+	patch   ItemPatch
+	tags    []string
+	parent  *int
+	item    Item
+	mode    DeleteMode
+	sub     *subscriber
+	since   uint64
+	limit   int
+	replyCh chan reply
 	errCh   chan error
 }
 
+// what a command answers with: the list and, for a mutation, the item it produced
+type reply struct {
+	items []Item
+	// the item the mutation added or changed, nil for reads, deletes and failures
+	item *Item
+}
+
+// a mutation whose reply is held back until its group commit reaches the store
+type pendingReply struct {
+	cmd    command
+	items  []Item
+	item   *Item
+	events []Event
+	change Change
+}
+
 // runs as a single actior go routine processing all commands
 type ListActor struct {
 	items  []Item
 	cmdCh  chan command
 	stopCh chan struct{}
+	done   chan struct{}
+	once   sync.Once
 	wg     sync.WaitGroup
+
+	//persistence, nil store keeps the list in memory only
+	store       Store
+	groupCommit time.Duration
+	committed   []Item //last state known to be saved, restored when a save fails
+	pending     []pendingReply
+	dirty       bool
+	flushTimer  *time.Timer
+
+	//change feed
+	subs      map[int]*subscriber
+	nextSubID int
+	seq       uint64
+	history   []Event //last DefaultEventRetention events, oldest first
+
+	index *searchIndex
 }
 
 func NewListActor(initial []Item) *ListActor {
@@ -38,105 +121,627 @@ func NewListActor(initial []Item) *ListActor {
 		items:  initial,
 		cmdCh:  make(chan command, 1000),
 		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+		subs:   map[int]*subscriber{},
+		index:  newSearchIndex(initial),
 	}
 	m.wg.Add(1)
 	go m.run()
 	return m
 }
 
+// loads the list from store and hands every mutation to its Apply before replying.
+// With groupCommit > 0 all mutations within that window share one write. When the store
+// could only recover its items from a backup the actor still starts, and the
+// *RecoveredError is returned along with it.
+func NewPersistentListActor(store Store, groupCommit time.Duration) (*ListActor, error) {
+	items, err := store.Load()
+	if err != nil && !IsRecovered(err) {
+		return nil, err
+	}
+	m := &ListActor{
+		items:       items,
+		cmdCh:       make(chan command, 1000),
+		stopCh:      make(chan struct{}),
+		done:        make(chan struct{}),
+		store:       store,
+		groupCommit: groupCommit,
+		committed:   append([]Item{}, items...),
+		subs:        map[int]*subscriber{},
+		index:       newSearchIndex(items),
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m, err
+}
+
 func (m *ListActor) run() {
 	defer m.wg.Done()
+	defer close(m.done)
+	defer m.closeSubscribers()
 	for {
+		var flushC <-chan time.Time
+		if m.flushTimer != nil {
+			flushC = m.flushTimer.C
+		}
+
 		select {
-		case cmd, ok := <-m.cmdCh:
-			if !ok {
-				return //close chanel, stop
-			}
-			switch cmd.cmdType {
-			case cmdAdd:
-				m.items = Add(m.items, cmd.value)
-				cmd.replyCh <- m.items
-				cmd.errCh <- nil
-
-			case cmdUpdateDesc:
-				updated, err := UpdateDescription(m.items, cmd.id, cmd.value)
-				if err == nil {
-					m.items = updated
-				}
-				cmd.replyCh <- m.items
-				cmd.errCh <- err
-
-			case cmdUpdateStatus:
-				updated, err := UpdateStatus(m.items, cmd.id, cmd.value)
-				if err == nil {
-					m.items = updated
-				}
-				cmd.replyCh <- m.items
-				cmd.errCh <- err
-
-			case cmdDelete:
-				m.items = Delete(m.items, cmd.id)
-				cmd.replyCh <- m.items
-				cmd.errCh <- nil
-
-			case cmdGetAll:
-				cmd.replyCh <- append([]Item{}, m.items...)
-				cmd.errCh <- nil
-			}
+		case cmd := <-m.cmdCh:
+			m.handle(cmd)
+		case <-flushC:
+			m.flushTimer = nil
+			m.flush()
 		case <-m.stopCh:
+			m.drain()
+			m.flush()
+			return
+		}
+	}
+}
+
+// processes whatever was already queued when Stop was called
+func (m *ListActor) drain() {
+	for {
+		select {
+		case cmd := <-m.cmdCh:
+			m.handle(cmd)
+		default:
 			return
 		}
 	}
 }
 
+func (m *ListActor) handle(cmd command) {
+	traceID := trace.GetTraceID(cmd.ctx)
+	//the caller gave up while the command was queued, don't apply it
+	if err := cmd.ctx.Err(); err != nil {
+		slog.Warn("List actor dropped expired command", "cmd", cmd.cmdType, "trace_id", traceID, "error", err)
+		cmd.replyCh <- reply{}
+		cmd.errCh <- err
+		return
+	}
+	slog.Info("List actor handling command", "cmd", cmd.cmdType, "id", cmd.id, "trace_id", traceID)
+
+	var err error
+	var before, after *Item
+	var events []Event
+	switch cmd.cmdType {
+	case cmdAdd:
+		if m.items, err = addTagged(m.items, cmd.value, cmd.parent, cmd.tags); err != nil {
+			break
+		}
+		after = m.find(m.items[len(m.items)-1].ID)
+
+	case cmdUpdateDesc:
+		var updated []Item
+		before = m.find(cmd.id)
+		updated, err = UpdateDescription(m.items, cmd.id, cmd.value)
+		if err == nil {
+			m.items = updated
+			after = m.find(cmd.id)
+		}
+
+	case cmdUpdateStatus:
+		var updated []Item
+		before = m.find(cmd.id)
+		updated, err = UpdateStatus(m.items, cmd.id, cmd.value)
+		if err == nil {
+			events = spawnedEvents(m.items, updated)
+			m.items = updated
+			after = m.find(cmd.id)
+		}
+
+	case cmdDelete:
+		before = m.find(cmd.id)
+		if before == nil {
+			err = fmt.Errorf("item with ID %d %w", cmd.id, ErrNotFound)
+			break
+		}
+		//besides the item itself, its subtasks are deleted or moved up
+		prev := m.items
+		m.items = Delete(m.items, cmd.id, cmd.mode)
+		events = subtreeEvents(prev, m.items, cmd.id)
+		before = nil
+
+	case cmdPatch:
+		var updated []Item
+		before = m.find(cmd.id)
+		updated, err = Patch(m.items, cmd.id, cmd.patch)
+		if err == nil {
+			events = spawnedEvents(m.items, updated)
+			m.items = updated
+			after = m.find(cmd.id)
+		}
+
+	case cmdMergeTags:
+		var updated []Item
+		var changed []int
+		updated, changed, err = MergeTags(m.snapshot(), cmd.value, cmd.tags...)
+		if err == nil {
+			//one event per retagged item, all committed together
+			for _, id := range changed {
+				after, _ := FindItem(updated, id)
+				events = append(events, newEvent(m.find(id), &after))
+			}
+			m.items = updated
+		}
+
+	case cmdImport:
+		m.items = Import(m.items, cmd.item)
+		after = m.find(m.items[len(m.items)-1].ID)
+
+	case cmdSearch:
+		cmd.replyCh <- reply{items: m.index.search(cmd.value, cmd.limit)}
+		cmd.errCh <- nil
+		return
+
+	case cmdGetAll:
+		cmd.replyCh <- reply{items: m.snapshot()}
+		cmd.errCh <- nil
+		return
+
+	case cmdSubscribe:
+		m.replay(cmd.sub, cmd.since)
+		m.nextSubID++
+		cmd.sub.id = m.nextSubID
+		m.subs[cmd.sub.id] = cmd.sub
+		cmd.replyCh <- reply{}
+		cmd.errCh <- nil
+		return
+
+	case cmdUnsubscribe:
+		if _, ok := m.subs[cmd.sub.id]; ok {
+			delete(m.subs, cmd.sub.id)
+			close(cmd.sub.ch)
+		}
+		cmd.replyCh <- reply{}
+		cmd.errCh <- nil
+		return
+	}
+
+	if err != nil {
+		slog.Warn("List actor command failed", "cmd", cmd.cmdType, "id", cmd.id, "trace_id", traceID, "error", err)
+		cmd.replyCh <- reply{items: m.snapshot()}
+		cmd.errCh <- err
+		return
+	}
+
+	if before != nil || after != nil {
+		events = append([]Event{newEvent(before, after)}, events...)
+	}
+	for _, ev := range events {
+		m.index.apply(ev)
+	}
+	m.persist(cmd, events)
+}
+
+// added events for the items a status change appended, the next occurrences of recurring items
+func spawnedEvents(before, after []Item) []Event {
+	var events []Event
+	for i := len(before); i < len(after); i++ {
+		item := after[i]
+		events = append(events, newEvent(nil, &item))
+	}
+	return events
+}
+
+// events for a delete: one per removed item, the deleted one first, then one per
+// subtask whose parent changed or item that stopped waiting on a removed one
+func subtreeEvents(before, after []Item, id int) []Event {
+	remaining := map[int]Item{}
+	for _, item := range after {
+		remaining[item.ID] = item
+	}
+	deleted, _ := FindItem(before, id)
+	events := []Event{newEvent(&deleted, nil)}
+	for _, item := range before {
+		if item.ID == id {
+			continue
+		}
+		now, ok := remaining[item.ID]
+		switch {
+		case !ok:
+			events = append(events, newEvent(&item, nil))
+		case !sameParent(now.ParentID, item.ParentID) || !slices.Equal(now.BlockedBy, item.BlockedBy):
+			events = append(events, newEvent(&item, &now))
+		}
+	}
+	return events
+}
+
+// copy of the item with id, nil when there is none
+func (m *ListActor) find(id int) *Item {
+	if item, ok := FindItem(m.items, id); ok {
+		return &item
+	}
+	return nil
+}
+
+// replies to a successful mutation once it is saved, or queues it for the next group commit.
+// Its events are only published once the change is committed.
+func (m *ListActor) persist(cmd command, events []Event) {
+	var item *Item
+	if len(events) > 0 {
+		item = events[0].After
+	}
+	if m.store == nil {
+		m.publish(events)
+		cmd.replyCh <- reply{items: m.snapshot(), item: item}
+		cmd.errCh <- nil
+		return
+	}
+
+	m.dirty = true
+	m.pending = append(m.pending, pendingReply{cmd: cmd, items: m.snapshot(), item: item, events: events, change: newChange(cmd, events)})
+	if m.groupCommit <= 0 {
+		m.flush()
+		return
+	}
+	if m.flushTimer == nil {
+		m.flushTimer = time.NewTimer(m.groupCommit)
+	}
+}
+
+// the change a mutation made, as the store persists it: the items of its events and
+// the command itself, so the store can redo it when the list changed elsewhere
+func newChange(cmd command, events []Event) Change {
+	c := Change{Op: cmd.cmdType.String(), ID: cmd.id, Value: cmd.value, Redo: redo(cmd)}
+	if len(events) > 0 {
+		c.ID = events[0].ID
+	}
+	for _, ev := range events {
+		if ev.After != nil {
+			c.Put = append(c.Put, *ev.After)
+		} else {
+			c.Remove = append(c.Remove, ev.ID)
+		}
+	}
+	return c
+}
+
+// the list function behind a mutating command. Besides the list it returns the ID of the
+// item the command is about, e.g. the ID a redone add got.
+func redo(cmd command) func([]Item) ([]Item, int, error) {
+	switch cmd.cmdType {
+	case cmdAdd:
+		return func(items []Item) ([]Item, int, error) {
+			items, err := addTagged(items, cmd.value, cmd.parent, cmd.tags)
+			if err != nil {
+				return items, 0, err
+			}
+			return items, items[len(items)-1].ID, nil
+		}
+	case cmdUpdateDesc:
+		return func(items []Item) ([]Item, int, error) {
+			items, err := UpdateDescription(items, cmd.id, cmd.value)
+			return items, cmd.id, err
+		}
+	case cmdUpdateStatus:
+		return func(items []Item) ([]Item, int, error) {
+			items, err := UpdateStatus(items, cmd.id, cmd.value)
+			return items, cmd.id, err
+		}
+	case cmdDelete:
+		return func(items []Item) ([]Item, int, error) {
+			if _, ok := FindItem(items, cmd.id); !ok {
+				return items, cmd.id, fmt.Errorf("item with ID %d %w", cmd.id, ErrNotFound)
+			}
+			return Delete(items, cmd.id, cmd.mode), cmd.id, nil
+		}
+	case cmdPatch:
+		return func(items []Item) ([]Item, int, error) {
+			items, err := Patch(items, cmd.id, cmd.patch)
+			return items, cmd.id, err
+		}
+	case cmdMergeTags:
+		return func(items []Item) ([]Item, int, error) {
+			updated, changed, err := MergeTags(items, cmd.value, cmd.tags...)
+			if err != nil || len(changed) == 0 {
+				return updated, -1, err
+			}
+			return updated, changed[0], nil
+		}
+	case cmdImport:
+		return func(items []Item) ([]Item, int, error) {
+			items = Import(items, cmd.item)
+			return items, items[len(items)-1].ID, nil
+		}
+	}
+	return nil
+}
+
+// hands the pending changes to the store and answers every held reply with the outcome.
+// A failed write rolls the list back to the last committed state. When the store merged
+// the changes into a list another process wrote to, the actor takes the merged list over.
+func (m *ListActor) flush() {
+	if m.flushTimer != nil {
+		m.flushTimer.Stop()
+		m.flushTimer = nil
+	}
+	if m.store == nil || !m.dirty {
+		return
+	}
+
+	changes := make([]Change, len(m.pending))
+	for i, p := range m.pending {
+		changes[i] = p.change
+	}
+	merged, redone, err := m.store.Apply(changes)
+	switch {
+	case err != nil:
+		slog.Error("List actor failed to persist items", "mutations", len(m.pending), "error", err)
+		m.items = append([]Item{}, m.committed...)
+		m.index = newSearchIndex(m.items)
+	case merged != nil:
+		slog.Info("List actor took over the list merged by the store", "mutations", len(m.pending), "count", len(merged))
+		m.items = append([]Item{}, merged...)
+		m.index = newSearchIndex(m.items)
+		m.committed = m.snapshot()
+	default:
+		m.committed = m.snapshot()
+	}
+	m.dirty = false
+
+	for i, p := range m.pending {
+		perr := err
+		if perr == nil && merged != nil {
+			perr = redone[i].Err
+		}
+		switch {
+		case err != nil:
+			slog.Error("List actor change not saved", "cmd", p.cmd.cmdType, "trace_id", trace.GetTraceID(p.cmd.ctx), "error", err)
+			p.cmd.replyCh <- reply{}
+		case perr != nil:
+			slog.Warn("List actor change no longer applies", "cmd", p.cmd.cmdType, "trace_id", trace.GetTraceID(p.cmd.ctx), "error", perr)
+			p.cmd.replyCh <- reply{items: m.snapshot()}
+		case merged != nil:
+			//the item as it ended up in the merged list, a redone add may have another ID
+			var item *Item
+			if found, ok := FindItem(m.items, redone[i].ID); ok {
+				item = &found
+			}
+			p.cmd.replyCh <- reply{items: m.snapshot(), item: item}
+		default:
+			m.publish(p.events)
+			p.cmd.replyCh <- reply{items: p.items, item: p.item}
+		}
+		p.cmd.errCh <- perr
+	}
+	//the events describe the actor's list, not the merged one, subscribers reload instead
+	if merged != nil {
+		m.publish([]Event{{Type: EventResync}})
+	}
+	m.pending = nil
+}
+
+// stamps the next sequence numbers on events and hands them to every subscriber
+func (m *ListActor) publish(events []Event) {
+	for _, ev := range events {
+		m.seq++
+		ev.Seq = m.seq
+		for _, sub := range m.subs {
+			sub.deliver(ev)
+		}
+		m.history = append(m.history, ev)
+	}
+	if len(m.history) > DefaultEventRetention {
+		m.history = append([]Event{}, m.history[len(m.history)-DefaultEventRetention:]...)
+	}
+}
+
+// queues the retained events after since for a resuming subscriber, or a resync
+// marker when some of them are no longer retained (or since is from another run)
+func (m *ListActor) replay(sub *subscriber, since uint64) {
+	if since == 0 || since == m.seq {
+		return
+	}
+	if since > m.seq || len(m.history) == 0 || since+1 < m.history[0].Seq {
+		sub.deliver(Event{Seq: m.seq + 1, Type: EventResync})
+		return
+	}
+	for _, ev := range m.history {
+		if ev.Seq > since {
+			sub.deliver(ev)
+		}
+	}
+}
+
+func (m *ListActor) closeSubscribers() {
+	for id, sub := range m.subs {
+		close(sub.ch)
+		delete(m.subs, id)
+	}
+}
+
+// replies get their own copy so callers never share the slice the actor keeps mutating
+func (m *ListActor) snapshot() []Item {
+	return append([]Item{}, m.items...)
+}
+
+// stops the actor after finishing queued commands and flushing unsaved changes
 func (m *ListActor) Stop() {
-	//closeing channels will signal shutdown
-	close(m.stopCh)
-	close(m.cmdCh)
+	m.once.Do(func() {
+		close(m.stopCh)
+	})
 	m.wg.Wait()
 }
 
+// queues cmd and waits for the list it replies with, see call
 func (m *ListActor) send(cmd command) ([]Item, error) {
+	r, err := m.call(cmd)
+	return r.items, err
+}
+
+// queues cmd and waits for its reply. A cancelled or expired ctx returns ctx.Err(),
+// commands that expire while still queued are dropped by the actor. A mutation the
+// actor already applied may still be committed after its caller stopped waiting.
+func (m *ListActor) call(cmd command) (reply, error) {
+	if err := cmd.ctx.Err(); err != nil {
+		return reply{}, err
+	}
 	select {
 	case <-m.stopCh:
-		return nil, ErrActorStopped
+		return reply{}, ErrActorStopped
 	default:
 	}
 	select {
 	case m.cmdCh <- cmd:
-		return <-cmd.replyCh, <-cmd.errCh
 	case <-m.stopCh:
-		return nil, ErrActorStopped
+		return reply{}, ErrActorStopped
+	case <-cmd.ctx.Done():
+		return reply{}, cmd.ctx.Err()
+	}
+	select {
+	case r := <-cmd.replyCh:
+		return r, <-cmd.errCh
+	case <-cmd.ctx.Done():
+		return reply{}, cmd.ctx.Err()
+	case <-m.done:
+		//the actor may have answered right before exiting
+		select {
+		case r := <-cmd.replyCh:
+			return r, <-cmd.errCh
+		default:
+			return reply{}, ErrActorStopped
+		}
+	}
+}
+
+func newCommand(ctx context.Context, cmdType commandType) command {
+	return command{ctx: ctx, cmdType: cmdType, replyCh: make(chan reply, 1), errCh: make(chan error, 1)}
+}
+
+func (m *ListActor) Add(ctx context.Context, desc string) ([]Item, error) {
+	cmd := newCommand(ctx, cmdAdd)
+	cmd.value = desc
+	return m.send(cmd)
+}
+
+// adds a subtask of the item with parentID, ErrInvalidParent when there is none
+func (m *ListActor) AddSubtask(ctx context.Context, parentID int, desc string) ([]Item, error) {
+	cmd := newCommand(ctx, cmdAdd)
+	cmd.value, cmd.parent = desc, &parentID
+	return m.send(cmd)
+}
+
+// adds an item carrying tags, a subtask when parentID is set, and returns it as it was
+// saved. With group commit or after a merge the new item needn't be the last one of the list.
+func (m *ListActor) AddItem(ctx context.Context, desc string, parentID *int, tags ...string) (Item, error) {
+	cmd := newCommand(ctx, cmdAdd)
+	cmd.value, cmd.parent, cmd.tags = desc, parentID, tags
+	r, err := m.call(cmd)
+	if err != nil {
+		return Item{}, err
+	}
+	if r.item == nil {
+		return Item{}, fmt.Errorf("added item %w", ErrNotFound)
+	}
+	return *r.item, nil
+}
+
+// adds a copy of an item from another list, see Import
+func (m *ListActor) Import(ctx context.Context, item Item) ([]Item, error) {
+	cmd := newCommand(ctx, cmdImport)
+	cmd.item = item
+	return m.send(cmd)
+}
+
+func (m *ListActor) UpdateDescription(ctx context.Context, id int, desc string) ([]Item, error) {
+	cmd := newCommand(ctx, cmdUpdateDesc)
+	cmd.id, cmd.value = id, desc
+	return m.send(cmd)
+}
+
+func (m *ListActor) UpdateStatus(ctx context.Context, id int, status string) ([]Item, error) {
+	cmd := newCommand(ctx, cmdUpdateStatus)
+	cmd.id, cmd.value = id, status
+	return m.send(cmd)
+}
+
+// deletes the item with id, its subtasks move up to its parent unless mode is DeleteCascade
+func (m *ListActor) Delete(ctx context.Context, id int, mode ...DeleteMode) ([]Item, error) {
+	cmd := newCommand(ctx, cmdDelete)
+	cmd.id = id
+	if len(mode) > 0 {
+		cmd.mode = mode[0]
 	}
+	return m.send(cmd)
+}
+
+// applies all fields of patch atomically
+func (m *ListActor) Patch(ctx context.Context, id int, patch ItemPatch) ([]Item, error) {
+	cmd := newCommand(ctx, cmdPatch)
+	cmd.id, cmd.patch = id, patch
+	return m.send(cmd)
 }
 
-func (m *ListActor) Add(desc string) ([]Item, error) {
-	reply := make(chan []Item, 1)
-	errCh := make(chan error, 1)
-	return m.send(command{cmdType: cmdAdd, value: desc, replyCh: reply, errCh: errCh})
+// renames the tag from to to on every item that has it
+func (m *ListActor) RenameTag(ctx context.Context, from, to string) ([]Item, error) {
+	return m.MergeTags(ctx, to, from)
 }
 
-func (m *ListActor) UpdateDescription(id int, desc string) ([]Item, error) {
-	reply := make(chan []Item, 1)
-	errCh := make(chan error, 1)
-	return m.send(command{cmdType: cmdUpdateDesc, id: id, value: desc, replyCh: reply, errCh: errCh})
+// replaces the tags from with into on every item, in one commit so no reader
+// ever sees the list half retagged
+func (m *ListActor) MergeTags(ctx context.Context, into string, from ...string) ([]Item, error) {
+	cmd := newCommand(ctx, cmdMergeTags)
+	cmd.value, cmd.tags = into, from
+	return m.send(cmd)
 }
 
-func (m *ListActor) UpdateStatus(id int, status string) ([]Item, error) {
-	reply := make(chan []Item, 1)
-	errCh := make(chan error, 1)
-	return m.send(command{cmdType: cmdUpdateStatus, id: id, value: status, replyCh: reply, errCh: errCh})
+// full-text search over descriptions and notes, best matches first. Every word of q
+// must match a word of the item, or the start of one. limit <= 0 uses DefaultSearchLimit.
+func (m *ListActor) Search(ctx context.Context, q string, limit int) ([]Item, error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	cmd := newCommand(ctx, cmdSearch)
+	cmd.value, cmd.limit = q, min(limit, MaxSearchLimit)
+	return m.send(cmd)
 }
 
-func (m *ListActor) Delete(id int) ([]Item, error) {
-	reply := make(chan []Item, 1)
-	errCh := make(chan error, 1)
-	return m.send(command{cmdType: cmdDelete, id: id, replyCh: reply, errCh: errCh})
+func (m *ListActor) GetAll(ctx context.Context) ([]Item, error) {
+	return m.send(newCommand(ctx, cmdGetAll))
+}
 
+// returns a feed of committed changes. The channel is closed once ctx is done or the
+// actor stops. A subscriber that falls behind by more than DefaultSubscriberBuffer
+// events loses them and receives an EventResync marker instead.
+func (m *ListActor) Subscribe(ctx context.Context) (<-chan Event, error) {
+	return m.subscribe(ctx, 0)
 }
 
-func (m *ListActor) GetAll() ([]Item, error) {
-	reply := make(chan []Item, 1)
-	errCh := make(chan error, 1)
-	return m.send(command{cmdType: cmdGetAll, replyCh: reply, errCh: errCh})
+// like Subscribe, but first replays the retained events with a sequence number after
+// since, so a client that reconnects misses nothing. When those events are no longer
+// retained the feed starts with an EventResync marker.
+func (m *ListActor) SubscribeSince(ctx context.Context, since uint64) (<-chan Event, error) {
+	return m.subscribe(ctx, since)
+}
+
+func (m *ListActor) subscribe(ctx context.Context, since uint64) (<-chan Event, error) {
+	//room for a full replay on top of the live buffer
+	buffer := DefaultSubscriberBuffer
+	if since > 0 {
+		buffer += DefaultEventRetention
+	}
+	sub := &subscriber{ch: make(chan Event, buffer)}
+	cmd := newCommand(ctx, cmdSubscribe)
+	cmd.sub = sub
+	cmd.since = since
+	_, err := m.send(cmd)
+
+	//also cleans up when ctx expired after the actor already registered the subscriber
+	go func() {
+		select {
+		case <-ctx.Done():
+			cmd := newCommand(context.Background(), cmdUnsubscribe)
+			cmd.sub = sub
+			m.send(cmd)
+		case <-m.done:
+		}
+	}()
+
+	if err != nil {
+		return nil, err
+	}
+	return sub.ch, nil
 }
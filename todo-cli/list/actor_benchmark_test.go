@@ -1,17 +1,19 @@
 package list
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
 
 // Benchmark adding items sequencially
 func BenchmarkActorAdd(b *testing.B) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 
 	for i := 0; i < b.N; i++ {
-		_, err := actor.Add(fmt.Sprintf("Benchmark Task %d", i))
+		_, err := actor.Add(ctx, fmt.Sprintf("Benchmark Task %d", i))
 		if err != nil {
 			b.Fatalf("Unexpected error: %v", err)
 		}
@@ -20,6 +22,7 @@ func BenchmarkActorAdd(b *testing.B) {
 
 // Benchmark adding items sequencially
 func BenchmarkActorConcurrentAddGet(b *testing.B) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 
@@ -27,9 +30,9 @@ func BenchmarkActorConcurrentAddGet(b *testing.B) {
 		i := 0
 		for pb.Next() {
 			if i%2 == 0 {
-				actor.Add(fmt.Sprintf("Parallel Task %d", i))
+				actor.Add(ctx, fmt.Sprintf("Parallel Task %d", i))
 			} else {
-				actor.GetAll()
+				actor.GetAll(ctx)
 			}
 			i++
 		}
@@ -38,14 +41,15 @@ func BenchmarkActorConcurrentAddGet(b *testing.B) {
 
 // benchmark full lifecycle: Add - Update - Get - Delete
 func BenchmarkActorFullLifecycle(b *testing.B) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 
 	for i := 0; i < b.N; i++ {
-		actor.Add(fmt.Sprintf("Task %d", i))
-		actor.UpdateStatus(i%5, "completed")
-		actor.UpdateDescription(i%5, "Updated Task")
-		actor.Delete(i % 3)
-		actor.GetAll()
+		actor.Add(ctx, fmt.Sprintf("Task %d", i))
+		actor.UpdateStatus(ctx, i%5, "completed")
+		actor.UpdateDescription(ctx, i%5, "Updated Task")
+		actor.Delete(ctx, i%3)
+		actor.GetAll(ctx)
 	}
 }
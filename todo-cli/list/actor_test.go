@@ -1,15 +1,24 @@
 package list
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
+	"todo-cli/trace"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListActor_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 
@@ -21,7 +30,7 @@ func TestListActor_ConcurrentAccess(t *testing.T) {
 		go func(n int) {
 			defer wg.Done()
 			desc := "Task " + string(rune('A'+n))
-			items, err := actor.Add(desc)
+			items, err := actor.Add(ctx, desc)
 			assert.NoError(t, err, "Add() should not return error")
 			assert.GreaterOrEqual(t, len(items), 1, "Items should not be empty after Add()")
 		}(i)
@@ -32,7 +41,7 @@ func TestListActor_ConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			items, err := actor.GetAll()
+			items, err := actor.GetAll(ctx)
 			assert.NoError(t, err, "GetAll() should not return error")
 			assert.NotNil(t, items, "GetAll() should return a non-nil slice")
 		}()
@@ -40,12 +49,13 @@ func TestListActor_ConcurrentAccess(t *testing.T) {
 
 	wg.Wait()
 
-	items, err := actor.GetAll()
+	items, err := actor.GetAll(ctx)
 	assert.NoError(t, err)
 	assert.NotNil(t, items, "Expected items after concurrent adds")
 }
 
 func TestListActor_Parallel(t *testing.T) {
+	ctx := context.Background()
 
 	//create one actor for both subtests
 	actor := NewListActor([]Item{})
@@ -58,12 +68,12 @@ func TestListActor_Parallel(t *testing.T) {
 	t.Run("AddParallel", func(t *testing.T) {
 		t.Parallel()
 		for i := 0; i < 100; i++ {
-			_, err := actor.Add(fmt.Sprintf("Concurrent Task %d", i))
+			_, err := actor.Add(ctx, fmt.Sprintf("Concurrent Task %d", i))
 			if err != nil && err != ErrActorStopped {
 				t.Errorf("unexpected error: %v", err)
 			}
 		}
-		items, err := actor.GetAll()
+		items, err := actor.GetAll(ctx)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, items, "Expected items after AddParallel")
 	})
@@ -71,7 +81,7 @@ func TestListActor_Parallel(t *testing.T) {
 	t.Run("GetParallel", func(t *testing.T) {
 		t.Parallel()
 		for i := 0; i < 10; i++ {
-			items, err := actor.GetAll()
+			items, err := actor.GetAll(ctx)
 			if err != nil && err != ErrActorStopped {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -84,38 +94,41 @@ func TestListActor_Parallel(t *testing.T) {
 
 // Tests graceful shutdown behavior
 func TestListActor_Stop(t *testing.T) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
-	_, err := actor.Add("Initial Task")
+	_, err := actor.Add(ctx, "Initial Task")
 	assert.NoError(t, err)
 
 	actor.Stop()
 
-	_, err = actor.Add("Should fail after stop")
+	_, err = actor.Add(ctx, "Should fail after stop")
 	assert.ErrorIs(t, err, ErrActorStopped, "Ading after stop should return ErrActorStopped")
 }
 
 func TestListActor_CommandSequence(t *testing.T) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 
 	//Adding multiple(5) items
 	for i := 0; i < 5; i++ {
-		_, err := actor.Add("Item " + string(rune('A'+i)))
+		_, err := actor.Add(ctx, "Item "+string(rune('A'+i)))
 		assert.NoError(t, err)
 	}
 
 	//Update status and description sequentially
-	items, err := actor.UpdateStatus(2, "completed")
+	items, err := actor.UpdateStatus(ctx, 2, "completed")
 	assert.NoError(t, err)
 	assert.Equal(t, "completed", items[2].Status)
 
-	items, err = actor.UpdateDescription(3, "Updated C")
+	items, err = actor.UpdateDescription(ctx, 3, "Updated C")
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated C", items[3].Description)
 
 }
 
 func TestListActor_Race(t *testing.T) {
+	ctx := context.Background()
 	actor := NewListActor([]Item{})
 	defer actor.Stop()
 	var wg sync.WaitGroup
@@ -123,17 +136,336 @@ func TestListActor_Race(t *testing.T) {
 		wg.Add(2)
 		go func(n int) {
 			defer wg.Done()
-			actor.Add("Race Task " + string(rune('A'+n%26)))
+			actor.Add(ctx, "Race Task "+string(rune('A'+n%26)))
 		}(i)
 		go func() {
 			defer wg.Done()
-			actor.GetAll()
+			actor.GetAll(ctx)
 		}()
 	}
 	wg.Wait()
 
 	time.Sleep(50 * time.Millisecond) //to let go routines to settle
-	items, err := actor.GetAll()
+	items, err := actor.GetAll(ctx)
 	assert.NoError(t, err)
 	assert.True(t, len(items) > 0)
 }
+
+// counts writes and can be told to fail them
+type countingStore struct {
+	*MemoryStore
+	mu    sync.Mutex
+	saves int
+	// whole list replacements, the actor should only ever apply changes
+	replaced int
+	fail     bool
+}
+
+func (s *countingStore) Apply(changes []Change) ([]Item, []Redone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return nil, nil, &WriteError{File: "items.json", Err: errors.New("disk full")}
+	}
+	s.saves++
+	return s.MemoryStore.Apply(changes)
+}
+
+func (s *countingStore) Save(items []Item) error {
+	s.mu.Lock()
+	s.replaced++
+	s.mu.Unlock()
+	return s.MemoryStore.Save(items)
+}
+
+func (s *countingStore) saveCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves
+}
+
+func TestListActor_WriteThrough(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil)}
+	actor, err := NewPersistentListActor(store, 0)
+	assert.NoError(t, err)
+	defer actor.Stop()
+
+	_, err = actor.Add(ctx, "Task A")
+	assert.NoError(t, err)
+	_, err = actor.UpdateStatus(ctx, 0, StatusStarted)
+	assert.NoError(t, err)
+
+	saved, _ := store.Load()
+	assert.Equal(t, 2, store.saveCount(), "every mutation should be saved")
+	assert.Equal(t, 0, store.replaced, "mutations should reach the store as changes")
+	assert.Equal(t, StatusStarted, saved[0].Status)
+
+	//failed updates change nothing, so nothing is saved
+	_, err = actor.UpdateStatus(ctx, 999, StatusStarted)
+	assert.Error(t, err)
+	assert.Equal(t, 2, store.saveCount())
+}
+
+func TestListActor_PersistFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil)}
+	actor, err := NewPersistentListActor(store, 0)
+	assert.NoError(t, err)
+	defer actor.Stop()
+
+	_, err = actor.Add(ctx, "Saved")
+	assert.NoError(t, err)
+
+	store.mu.Lock()
+	store.fail = true
+	store.mu.Unlock()
+
+	_, err = actor.Add(ctx, "Not saved")
+	assert.True(t, IsStorageError(err), "expected a storage error, got %v", err)
+
+	items, err := actor.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1, "unsaved change should be rolled back")
+}
+
+func TestListActor_GroupCommit(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil)}
+	actor, err := NewPersistentListActor(store, 50*time.Millisecond)
+	assert.NoError(t, err)
+	defer actor.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			_, err := actor.Add(ctx, fmt.Sprintf("Batched %d", n))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	//every caller got its reply only after the batch reached the store
+	saved, _ := store.Load()
+	assert.Len(t, saved, 20)
+	assert.Less(t, store.saveCount(), 20, "mutations should share saves")
+}
+
+func TestListActor_StopFlushesPendingChanges(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil)}
+	actor, err := NewPersistentListActor(store, time.Hour)
+	assert.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := actor.Add(ctx, "Waiting for flush")
+		done <- err
+	}()
+
+	//wait until the add is applied but not yet saved
+	assert.Eventually(t, func() bool {
+		items, _ := actor.GetAll(ctx)
+		return len(items) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, store.saveCount())
+
+	actor.Stop()
+	assert.NoError(t, <-done)
+
+	saved, _ := store.Load()
+	assert.Len(t, saved, 1)
+}
+
+// blocks every write until released, to keep the actor busy
+type blockingStore struct {
+	*MemoryStore
+	release chan struct{}
+}
+
+func (s *blockingStore) Apply(changes []Change) ([]Item, []Redone, error) {
+	<-s.release
+	return s.MemoryStore.Apply(changes)
+}
+
+func TestListActor_CancelledContext(t *testing.T) {
+	actor := NewListActor([]Item{})
+	defer actor.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := actor.Add(ctx, "Never added")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	items, err := actor.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestListActor_DropsCommandsExpiredWhileQueued(t *testing.T) {
+	store := &blockingStore{MemoryStore: NewMemoryStore(nil), release: make(chan struct{})}
+	actor, err := NewPersistentListActor(store, 0)
+	assert.NoError(t, err)
+	defer actor.Stop()
+
+	//first add keeps the actor busy inside Save
+	first := make(chan error, 1)
+	go func() {
+		_, err := actor.Add(context.Background(), "Slow save")
+		first <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	time.Sleep(5 * time.Millisecond)
+	_, err = actor.Add(ctx, "Expires in the queue")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(store.release)
+	assert.NoError(t, <-first)
+
+	items, err := actor.GetAll(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, items, 1, "expired command should have been dropped")
+}
+
+func TestListActor_LogsTraceID(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	orig := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&lockedWriter{w: &buf, mu: &mu}, nil)))
+	t.Cleanup(func() { slog.SetDefault(orig) })
+
+	actor := NewListActor([]Item{})
+	defer actor.Stop()
+
+	ctx := trace.WithTraceID(context.Background(), "trace-1234")
+	_, err := actor.Add(ctx, "Traced")
+	assert.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, buf.String(), `"trace_id":"trace-1234"`)
+}
+
+type lockedWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// every mutation is one journal record, the snapshot is only written on compaction
+func TestListActor_JournalsEachChange(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "items.json")
+	store := openJournal(t, file, 100)
+	actor, err := NewPersistentListActor(store, 0)
+	require.NoError(t, err)
+	defer actor.Stop()
+
+	_, err = actor.Add(ctx, "Launch")
+	require.NoError(t, err)
+	_, err = actor.AddSubtask(ctx, 0, "Design")
+	require.NoError(t, err)
+	_, err = actor.Add(ctx, "Taxes")
+	require.NoError(t, err)
+	want, err := actor.Delete(ctx, 0, DeleteCascade)
+	require.NoError(t, err)
+
+	assert.Len(t, journalLines(t, file), 4)
+	snapshot, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Empty(t, snapshot, "no mutation should rewrite the snapshot")
+
+	actor.Stop()
+	require.NoError(t, store.Close())
+	got, err := openJournal(t, file, 100).Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// two actors on the same data file, as two processes would have, keep each other's writes
+func TestListActor_MergesWritesOfOtherProcesses(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "items.json")
+	mine, err := NewPersistentListActor(NewFileStore(file, DefaultLockTimeout), 0)
+	require.NoError(t, err)
+	defer mine.Stop()
+	theirs, err := NewPersistentListActor(NewFileStore(file, DefaultLockTimeout), 0)
+	require.NoError(t, err)
+	defer theirs.Stop()
+
+	events, err := mine.Subscribe(ctx)
+	require.NoError(t, err)
+
+	_, err = mine.Add(ctx, "Mine")
+	require.NoError(t, err)
+	_, err = theirs.Add(ctx, "Theirs")
+	require.NoError(t, err)
+	items, err := mine.UpdateStatus(ctx, 0, StatusStarted)
+	require.NoError(t, err)
+
+	require.Len(t, items, 2)
+	assert.Equal(t, StatusStarted, items[0].Status)
+	assert.Equal(t, "Theirs", items[1].Description)
+	saved, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, items, saved)
+
+	assert.Equal(t, EventItemAdded, nextEvent(t, events).Type)
+	assert.Equal(t, EventResync, nextEvent(t, events).Type, "a merged list should make subscribers reload")
+
+	//a change that no longer applies to the merged list fails alone
+	_, err = theirs.Delete(ctx, 0)
+	require.NoError(t, err)
+	_, err = mine.UpdateDescription(ctx, 0, "Gone")
+	assert.ErrorIs(t, err, ErrNotFound)
+	items, err = mine.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestListActor_RepliesWithItsOwnItemAfterMerge(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "items.json")
+	mine, err := NewPersistentListActor(NewFileStore(file, DefaultLockTimeout), 0)
+	require.NoError(t, err)
+	defer mine.Stop()
+	theirs, err := NewPersistentListActor(NewFileStore(file, DefaultLockTimeout), 0)
+	require.NoError(t, err)
+	defer theirs.Stop()
+
+	cmd := newCommand(ctx, cmdAdd)
+	cmd.value = "Mine"
+	r, err := mine.call(cmd)
+	require.NoError(t, err)
+	require.NotNil(t, r.item)
+	assert.Equal(t, 0, r.item.ID)
+
+	_, err = theirs.Add(ctx, "Theirs")
+	require.NoError(t, err)
+
+	//redone on the merged list the add gets the ID after theirs, not the one of its own copy
+	cmd = newCommand(ctx, cmdAdd)
+	cmd.value = "Again"
+	r, err = mine.call(cmd)
+	require.NoError(t, err)
+	require.NotNil(t, r.item)
+	assert.Equal(t, 2, r.item.ID)
+	assert.Equal(t, "Again", r.item.Description)
+
+	cmd = newCommand(ctx, cmdUpdateStatus)
+	cmd.id, cmd.value = 0, StatusStarted
+	r, err = mine.call(cmd)
+	require.NoError(t, err)
+	require.NotNil(t, r.item)
+	assert.Equal(t, StatusStarted, r.item.Status)
+}
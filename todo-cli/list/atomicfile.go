@@ -0,0 +1,109 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// suffix of the previous generation kept next to the data file
+const backupSuffix = ".bak"
+
+// fsync hook, replaced in tests to simulate a crash in the middle of a save
+var syncFile = func(f *os.File) error {
+	return f.Sync()
+}
+
+func backupPath(filename string) string {
+	return filename + backupSuffix
+}
+
+// writes data to filename so that readers only ever see the old or the new content.
+// The data goes to a temp file in the same directory and is fsynced. The current file
+// is hard linked as the .bak generation, so the data file never stops existing, and a
+// single rename puts the temp file in its place. The directory is fsynced last so the
+// rename survives a crash too.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(filename, data, perm, true)
+}
+
+// like WriteFileAtomic, but without a .bak generation, for files whose old content must
+// not linger next to them, e.g. API keys that were revoked since
+func ReplaceFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	return writeFileAtomic(filename, data, perm, false)
+}
+
+func writeFileAtomic(filename string, data []byte, perm os.FileMode, backup bool) error {
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	//anything left behind on failure is a half written temp file, never the data file
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := syncFile(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return fmt.Errorf("chmod temp file: %w", err)
+	}
+
+	if backup {
+		if err := keepBackup(filename); err != nil {
+			return fmt.Errorf("keep backup: %w", err)
+		}
+	}
+	if err := renameFile(tmpName, filename); err != nil {
+		return fmt.Errorf("replace data file: %w", err)
+	}
+	return syncDir(dir)
+}
+
+// rename hook, replaced in tests to simulate a crash right before the data file is replaced
+var renameFile = os.Rename
+
+// makes the current data file the .bak generation without moving it. A hard link
+// shares the old content, filesystems without hard links get a copy with the same mode.
+func keepBackup(filename string) error {
+	bak := backupPath(filename)
+	if err := os.Remove(bak); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err := os.Link(filename, bak)
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		//no data file yet, nothing to keep
+		return nil
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(bak, data, info.Mode().Perm())
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+	return nil
+}
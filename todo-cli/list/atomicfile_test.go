@@ -0,0 +1,124 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generation(desc string) []Item {
+	return []Item{{ID: 1, Description: desc, Status: StatusNotStarted}}
+}
+
+func TestSaveToFile_KeepsPreviousGenerationAsBackup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+
+	require.NoError(t, SaveToFile(file, generation("first")))
+	require.NoError(t, SaveToFile(file, generation("second")))
+
+	loaded, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, generation("second"), loaded)
+
+	backup, err := readItems(backupPath(file))
+	require.NoError(t, err)
+	assert.Equal(t, generation("first"), backup)
+}
+
+// a writer that bypassed the atomic save left a torn file, the backup must be used
+func TestLoadFromFile_FallsBackToBackupOnTruncatedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	require.NoError(t, SaveToFile(file, generation("first")))
+	require.NoError(t, SaveToFile(file, generation("second")))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, data[:len(data)/2], 0644))
+
+	//the backup is used, and the caller learns the data file was corrupt
+	loaded, err := LoadFromFile(file)
+	var recovered *RecoveredError
+	require.ErrorAs(t, err, &recovered)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	assert.Equal(t, file, recovered.File)
+	assert.Equal(t, generation("first"), loaded)
+}
+
+// an actor starts on the recovered items, reports it and its first save rewrites the data file
+func TestNewPersistentListActor_RecoveredFromBackup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	require.NoError(t, SaveToFile(file, generation("first")))
+	require.NoError(t, SaveToFile(file, generation("second")))
+	require.NoError(t, os.WriteFile(file, []byte(`[{"id": 1,`), 0644))
+
+	actor, err := NewPersistentListActor(NewFileStore(file, 0), 0)
+	require.True(t, IsRecovered(err), "expected a RecoveredError, got %v", err)
+	require.NotNil(t, actor)
+	defer actor.Stop()
+
+	_, err = actor.Add(context.Background(), "After recovery")
+	require.NoError(t, err)
+	saved, err := readItems(file)
+	require.NoError(t, err)
+	assert.Len(t, saved, 2)
+	assert.Equal(t, "first", saved[0].Description)
+}
+
+// simulates a crash after the temp file was partially written but before the rename
+func TestSaveToFile_FailedWriteLeavesDataFileIntact(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "items.json")
+	require.NoError(t, SaveToFile(file, generation("first")))
+
+	orig := syncFile
+	syncFile = func(f *os.File) error { return errors.New("simulated crash") }
+	t.Cleanup(func() { syncFile = orig })
+
+	assert.Error(t, SaveToFile(file, generation("second")))
+
+	loaded, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, generation("first"), loaded)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp file should be left behind")
+}
+
+func TestLoadFromFile_IgnoresLeftoverTempFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "items.json")
+	require.NoError(t, SaveToFile(file, generation("first")))
+
+	//a half written temp file from a process that died mid-save
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "items.json.tmp-123"), []byte(`[{"id": 1, "desc`), 0644))
+
+	loaded, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, generation("first"), loaded)
+}
+
+// simulates a crash after the backup was made but before the new file replaced the old one
+func TestSaveToFile_DataFileExistsUntilReplaced(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	require.NoError(t, SaveToFile(file, generation("first")))
+
+	orig := renameFile
+	renameFile = func(string, string) error { return errors.New("simulated crash") }
+	t.Cleanup(func() { renameFile = orig })
+
+	assert.Error(t, SaveToFile(file, generation("second")))
+
+	//the primary is still there, not only its backup
+	primary, err := readItems(file)
+	require.NoError(t, err)
+	assert.Equal(t, generation("first"), primary)
+	backup, err := readItems(backupPath(file))
+	require.NoError(t, err)
+	assert.Equal(t, generation("first"), backup)
+}
@@ -0,0 +1,34 @@
+package list
+
+import "time"
+
+// source of the item timestamps, swapped out by tests through SetClock
+var now = time.Now
+
+// replaces the clock used for item timestamps and returns a func restoring the previous one
+func SetClock(clock func() time.Time) (restore func()) {
+	prev := now
+	now = clock
+	return func() { now = prev }
+}
+
+// the current time from the configured clock, for overdue checks outside the package
+func Now() time.Time {
+	return now()
+}
+
+// current time for item timestamps, in UTC so it survives a JSON round trip unchanged
+func timestamp() time.Time {
+	return now().UTC()
+}
+
+// layout used wherever timestamps are shown to people
+const TimeLayout = "2006-01-02 15:04"
+
+// formats t for tables, unset times are shown as "-"
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(TimeLayout)
+}
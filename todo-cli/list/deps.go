@@ -0,0 +1,232 @@
+package list
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// makes the item with id wait on blocker. Blocking an item on itself, on a missing
+// item or on anything that already waits on it (directly or not) is refused.
+func AddBlocker(items []Item, id, blocker int) ([]Item, error) {
+	i := indexOf(items, id)
+	if i < 0 {
+		return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	if blocker == id {
+		return items, fmt.Errorf("%w: item %d can't wait on itself", ErrInvalidDependency, id)
+	}
+	if indexOf(items, blocker) < 0 {
+		return items, fmt.Errorf("%w: no item with ID %d", ErrInvalidDependency, blocker)
+	}
+	if chain := dependencyPath(items, blocker, id); chain != nil {
+		return items, fmt.Errorf("%w: %s", ErrDependencyCycle, formatChain(append(chain, blocker)))
+	}
+	if slices.Contains(items[i].BlockedBy, blocker) {
+		return items, nil
+	}
+
+	blockedBy := append(slices.Clone(items[i].BlockedBy), blocker)
+	slices.Sort(blockedBy)
+	items[i].BlockedBy = blockedBy
+	items[i].UpdatedAt = timestamp()
+	return items, nil
+}
+
+func RemoveBlocker(items []Item, id, blocker int) ([]Item, error) {
+	i := indexOf(items, id)
+	if i < 0 {
+		return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	if !slices.Contains(items[i].BlockedBy, blocker) {
+		return items, nil
+	}
+	blockedBy := slices.DeleteFunc(slices.Clone(items[i].BlockedBy), func(b int) bool { return b == blocker })
+	if len(blockedBy) == 0 {
+		blockedBy = nil
+	}
+	items[i].BlockedBy = blockedBy
+	items[i].UpdatedAt = timestamp()
+	return items, nil
+}
+
+// the path of blockers from id to target (both included) when id waits on target,
+// directly or through other items, nil otherwise
+func dependencyPath(items []Item, id, target int) []int {
+	byID := indexByID(items)
+	visited := map[int]bool{}
+	var walk func(id int) []int
+	walk = func(id int) []int {
+		if id == target {
+			return []int{id}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		for _, b := range byID[id].BlockedBy {
+			if path := walk(b); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+	return walk(id)
+}
+
+func indexByID(items []Item) map[int]Item {
+	byID := make(map[int]Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return byID
+}
+
+// blockers of the item with id that are not done yet
+func OpenBlockers(items []Item, id int) []int {
+	item, _ := FindItem(items, id)
+	var open []int
+	for _, b := range item.BlockedBy {
+		if blocker, ok := FindItem(items, b); ok && !blocker.IsDone() {
+			open = append(open, b)
+		}
+	}
+	return open
+}
+
+// "2 -> 5 -> 2"
+func formatChain(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// "3, 4"
+func formatIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// an item in a dependency chain, Depth 1 for direct blockers
+type DependencyNode struct {
+	Item
+	Depth int `json:"depth"`
+}
+
+// what the item with id waits on and what waits on it
+type Dependencies struct {
+	ID int `json:"id"`
+	// every blocker, directly or through other items, depth first from the direct ones.
+	// An item reachable along several paths is listed once.
+	Chain []DependencyNode `json:"chain"`
+	// items blocked by this one directly
+	Blocks []Item `json:"blocks"`
+	// true when no blocker is open, so the item can be started
+	Ready bool `json:"ready"`
+}
+
+func DependenciesOf(items []Item, id int) (Dependencies, error) {
+	byID := indexByID(items)
+	item, ok := byID[id]
+	if !ok {
+		return Dependencies{}, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	deps := Dependencies{ID: id, Chain: []DependencyNode{}, Blocks: []Item{}, Ready: len(OpenBlockers(items, id)) == 0}
+
+	seen := map[int]bool{id: true}
+	var walk func(blockers []int, depth int)
+	walk = func(blockers []int, depth int) {
+		for _, b := range blockers {
+			blocker, ok := byID[b]
+			if !ok || seen[b] {
+				continue
+			}
+			seen[b] = true
+			deps.Chain = append(deps.Chain, DependencyNode{Item: blocker, Depth: depth})
+			walk(blocker.BlockedBy, depth+1)
+		}
+	}
+	walk(item.BlockedBy, 1)
+
+	for _, other := range items {
+		if slices.Contains(other.BlockedBy, id) {
+			deps.Blocks = append(deps.Blocks, other)
+		}
+	}
+	return deps, nil
+}
+
+// an open item in the work plan. Level is the length of the longest chain of open
+// blockers in front of it, level 0 items can be worked on now.
+type PlanStep struct {
+	Item
+	Level int `json:"level"`
+}
+
+// orders the open items topologically: everything an item waits on comes at a lower
+// level, and within a level more urgent (priority, then due date) work comes first.
+func Plan(items []Item) []PlanStep {
+	byID := indexByID(items)
+	levels := map[int]int{}
+	var level func(id int, path map[int]bool) int
+	level = func(id int, path map[int]bool) int {
+		if l, ok := levels[id]; ok {
+			return l
+		}
+		//a cycle can only come from a hand edited file, don't loop on it
+		path[id] = true
+		l := 0
+		for _, b := range byID[id].BlockedBy {
+			blocker, ok := byID[b]
+			if !ok || blocker.IsDone() || path[b] {
+				continue
+			}
+			l = max(l, level(b, path)+1)
+		}
+		delete(path, id)
+		levels[id] = l
+		return l
+	}
+
+	var steps []PlanStep
+	for _, item := range items {
+		if !item.IsDone() {
+			steps = append(steps, PlanStep{Item: item, Level: level(item.ID, map[int]bool{})})
+		}
+	}
+	open := make([]Item, len(steps))
+	for i, step := range steps {
+		open[i] = step.Item
+	}
+	Sort(open, []SortKey{{Field: SortPriority}, {Field: SortDue}, {Field: SortID}})
+	rank := map[int]int{}
+	for i, item := range open {
+		rank[item.ID] = i
+	}
+	sort.SliceStable(steps, func(a, b int) bool {
+		if steps[a].Level != steps[b].Level {
+			return steps[a].Level < steps[b].Level
+		}
+		return rank[steps[a].ID] < rank[steps[b].ID]
+	})
+	return steps
+}
+
+// the open items that are not waiting on anything, most urgent first
+func Next(items []Item) []Item {
+	next := []Item{}
+	for _, step := range Plan(items) {
+		if step.Level > 0 {
+			break
+		}
+		next = append(next, step.Item)
+	}
+	return next
+}
@@ -0,0 +1,138 @@
+package list_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"todo-cli/list"
+)
+
+// 0 Design, 1 Build (waits on 0), 2 Test (waits on 1), 3 Docs
+func depItems(t *testing.T) []list.Item {
+	var items []list.Item
+	for _, desc := range []string{"Design", "Build", "Test", "Docs"} {
+		items = list.Add(items, desc)
+	}
+	var err error
+	if items, err = list.AddBlocker(items, 1, 0); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if items, err = list.AddBlocker(items, 2, 1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return items
+}
+
+func TestUpdateStatus_Blocked(t *testing.T) {
+	items := depItems(t)
+
+	_, err := list.UpdateStatus(items, 1, list.StatusStarted)
+	if !errors.Is(err, list.ErrBlocked) || !strings.Contains(err.Error(), "waiting on 0") {
+		t.Fatalf("Expected ErrBlocked naming blocker 0, got %v", err)
+	}
+	if items[1].Status != list.StatusNotStarted {
+		t.Errorf("Blocked item changed status to %q", items[1].Status)
+	}
+
+	items, err = list.OverrideStatus(items, 2, list.StatusCompleted)
+	if err != nil || items[2].Status != list.StatusCompleted {
+		t.Errorf("Expected the override to complete Test, got %q (%v)", items[2].Status, err)
+	}
+
+	items, _ = list.UpdateStatus(items, 0, list.StatusCompleted)
+	if items, err = list.UpdateStatus(items, 1, list.StatusStarted); err != nil {
+		t.Errorf("Expected Build to start once Design is done, got %v", err)
+	}
+
+	done := list.StatusCompleted
+	force := list.ItemPatch{Status: &done, Force: true}
+	if _, err := list.Patch(depItems(t), 2, force); err != nil {
+		t.Errorf("Expected a forced patch to complete a blocked item, got %v", err)
+	}
+}
+
+func TestAddBlocker(t *testing.T) {
+	items := depItems(t)
+
+	_, err := list.AddBlocker(items, 0, 2)
+	if !errors.Is(err, list.ErrDependencyCycle) || !strings.Contains(err.Error(), "2 -> 1 -> 0 -> 2") {
+		t.Errorf("Expected a cycle through 2 -> 1 -> 0 -> 2, got %v", err)
+	}
+	if _, err := list.AddBlocker(items, 3, 3); !errors.Is(err, list.ErrInvalidDependency) {
+		t.Errorf("Expected ErrInvalidDependency blocking an item on itself, got %v", err)
+	}
+	if _, err := list.AddBlocker(items, 3, 99); !errors.Is(err, list.ErrInvalidDependency) {
+		t.Errorf("Expected ErrInvalidDependency for a missing blocker, got %v", err)
+	}
+	if _, err := list.AddBlocker(items, 99, 0); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	//blocking twice is a no-op
+	items, _ = list.AddBlocker(items, 1, 0)
+	if !equalIDs(items[1].BlockedBy, []int{0}) {
+		t.Errorf("Expected Build blocked by [0], got %v", items[1].BlockedBy)
+	}
+
+	items, _ = list.RemoveBlocker(items, 1, 0)
+	if items[1].BlockedBy != nil {
+		t.Errorf("Expected no blockers, got %v", items[1].BlockedBy)
+	}
+}
+
+func TestDelete_Blockers(t *testing.T) {
+	items := list.Delete(depItems(t), 1)
+	if test := items[1]; test.ID != 2 || test.BlockedBy != nil {
+		t.Errorf("Expected Test to stop waiting on the deleted item, got %+v", test)
+	}
+}
+
+func TestDependenciesOf(t *testing.T) {
+	items := depItems(t)
+
+	deps, err := list.DependenciesOf(items, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	var chain, depths []int
+	for _, node := range deps.Chain {
+		chain = append(chain, node.ID)
+		depths = append(depths, node.Depth)
+	}
+	if !equalIDs(chain, []int{1, 0}) || !equalIDs(depths, []int{1, 2}) || deps.Ready {
+		t.Errorf("Unexpected dependencies %+v", deps)
+	}
+
+	deps, _ = list.DependenciesOf(items, 0)
+	if len(deps.Blocks) != 1 || deps.Blocks[0].ID != 1 || !deps.Ready {
+		t.Errorf("Expected Design to block Build and be ready, got %+v", deps)
+	}
+
+	if _, err := list.DependenciesOf(items, 99); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	items := depItems(t)
+	items, _ = list.SetPriority(items, 3, list.PriorityP0)
+
+	var got, levels []int
+	for _, step := range list.Plan(items) {
+		got = append(got, step.ID)
+		levels = append(levels, step.Level)
+	}
+	//Docs is more urgent than Design, both can start now
+	if !equalIDs(got, []int{3, 0, 1, 2}) || !equalIDs(levels, []int{0, 0, 1, 2}) {
+		t.Errorf("Unexpected plan %v levels %v", got, levels)
+	}
+
+	items, _ = list.UpdateStatus(items, 0, list.StatusCompleted)
+	var next []int
+	for _, item := range list.Next(items) {
+		next = append(next, item.ID)
+	}
+	if !equalIDs(next, []int{3, 1}) {
+		t.Errorf("Expected Docs and Build next, got %v", next)
+	}
+}
@@ -0,0 +1,61 @@
+package list
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// layout of a due date given without a time, e.g. 2026-11-01
+const DateLayout = "2006-01-02"
+
+// parses a due date as RFC 3339 (keeping its offset) or as a plain date, which means the
+// end of that day in loc. An empty string or "none" returns the zero time (no due date).
+func ParseDue(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "none") {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(DateLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q, use YYYY-MM-DD or RFC 3339", ErrInvalidDue, s)
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second), nil
+}
+
+// sets (or with a zero time clears) the due date of the item with id
+func SetDue(items []Item, id int, due time.Time) ([]Item, error) {
+	for i, item := range items {
+		if item.ID == id {
+			items[i].DueAt = due
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// an open item whose due date has passed at the given time
+func (i Item) IsOverdue(at time.Time) bool {
+	return !i.DueAt.IsZero() && !i.IsDone() && at.After(i.DueAt)
+}
+
+// open items due before t (overdue ones included), soonest first
+func DueBefore(items []Item, t time.Time) []Item {
+	due := []Item{}
+	for _, item := range items {
+		if !item.DueAt.IsZero() && !item.IsDone() && item.DueAt.Before(t) {
+			due = append(due, item)
+		}
+	}
+	Sort(due, []SortKey{{Field: SortDue}})
+	return due
+}
+
+// open items due within the next days days of at, overdue ones included
+func Upcoming(items []Item, at time.Time, days int) []Item {
+	return DueBefore(items, at.AddDate(0, 0, days))
+}
@@ -0,0 +1,100 @@
+package list_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-cli/list"
+)
+
+func TestParseDue(t *testing.T) {
+	lisbon := time.FixedZone("WET", 0)
+	ny := time.FixedZone("EST", -5*3600)
+
+	due, err := list.ParseDue("2026-11-01", ny)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	want := time.Date(2026, 11, 1, 23, 59, 59, 0, ny)
+	if !due.Equal(want) {
+		t.Errorf("Expected a plain date to mean the end of that day %v, got %v", want, due)
+	}
+
+	due, err = list.ParseDue("2026-11-01T17:00:00+02:00", lisbon)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, offset := due.Zone(); offset != 2*3600 {
+		t.Errorf("Expected the given offset to be kept, got %d", offset)
+	}
+
+	if due, err := list.ParseDue("none", ny); err != nil || !due.IsZero() {
+		t.Errorf("Expected 'none' to clear the due date, got %v (%v)", due, err)
+	}
+	if _, err := list.ParseDue("next tuesday", ny); !errors.Is(err, list.ErrInvalidDue) {
+		t.Errorf("Expected ErrInvalidDue, got %v", err)
+	}
+}
+
+func TestOverdueAndUpcoming(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	items := []list.Item{
+		{ID: 1, Description: "No due", Status: list.StatusNotStarted},
+		{ID: 2, Description: "In ten days", Status: list.StatusNotStarted, DueAt: now.AddDate(0, 0, 10)},
+		{ID: 3, Description: "Tomorrow", Status: list.StatusStarted, DueAt: now.AddDate(0, 0, 1)},
+		{ID: 4, Description: "Yesterday", Status: list.StatusNotStarted, DueAt: now.AddDate(0, 0, -1)},
+		{ID: 5, Description: "Done late", Status: list.StatusCompleted, DueAt: now.AddDate(0, 0, -2)},
+	}
+
+	if !items[3].IsOverdue(now) || items[4].IsOverdue(now) || items[0].IsOverdue(now) || items[2].IsOverdue(now) {
+		t.Errorf("Only open items past their due date are overdue")
+	}
+
+	upcoming := list.Upcoming(items, now, 7)
+	if len(upcoming) != 2 || upcoming[0].ID != 4 || upcoming[1].ID != 3 {
+		t.Errorf("Expected items 4 and 3, got %+v", upcoming)
+	}
+
+	list.Sort(items, []list.SortKey{{Field: list.SortDue}})
+	var ids []int
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if want := []int{5, 4, 3, 2, 1}; !equalIDs(ids, want) {
+		t.Errorf("Expected order %v, got %v", want, ids)
+	}
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// the offset given by the user survives a save and load
+func TestDue_RoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	due := "2026-11-01T09:30:00-03:00"
+	items, err := list.Patch(sampleItems(), 1, list.ItemPatch{Due: &due})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := list.SaveToFile(file, items); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	loaded, err := list.LoadFromFile(file)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := loaded[0].DueAt.Format(time.RFC3339); got != due {
+		t.Errorf("Expected %s, got %s", due, got)
+	}
+}
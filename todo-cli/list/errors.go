@@ -0,0 +1,95 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+var (
+	// the data file is missing, callers usually start with an empty list
+	ErrNotExist = errors.New("data file does not exist")
+	// the data file (and its backup) could not be parsed
+	ErrCorrupt = errors.New("data file is corrupt")
+	// no item has the requested ID
+	ErrNotFound = errors.New("not found")
+	// the status is not one of the known statuses
+	ErrInvalidStatus = errors.New("invalid status")
+	// the due date could not be parsed
+	ErrInvalidDue = errors.New("invalid due date")
+	// the priority is not one of P0 to P3
+	ErrInvalidPriority = errors.New("invalid priority")
+	// a sort spec names a field that cannot be sorted on
+	ErrInvalidSort = errors.New("invalid sort")
+	// a tag is empty, has whitespace or starts with +, - or !
+	ErrInvalidTag = errors.New("invalid tag")
+	// the parent of a subtask does not exist
+	ErrInvalidParent = errors.New("invalid parent")
+	// moving an item under one of its own subtasks
+	ErrCycle = errors.New("parent would create a cycle")
+	// a blocker that does not exist or is the item itself
+	ErrInvalidDependency = errors.New("invalid dependency")
+	// a blocker that (indirectly) waits on the item it would block
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	// starting or completing an item whose blockers are not completed yet
+	ErrBlocked = errors.New("blocked")
+	// a recurrence rule outside the supported RRULE subset
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+	// the workflow doesn't allow moving from the item's status to the requested one
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// a workflow config that names a status twice, refers to unknown statuses or has no done status
+	ErrInvalidWorkflow = errors.New("invalid workflow")
+	// a list name with characters other than letters, digits, _ and -, or moving an item to its own list
+	ErrInvalidList = errors.New("invalid list")
+	// a list that isn't available, e.g. any but the default one when serving a single list
+	ErrListNotFound = errors.New("list not found")
+	// a user name with characters other than letters, digits, _ and -
+	ErrInvalidUser = errors.New("invalid user")
+	// a user that isn't served, e.g. any but the default one when serving a single user
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// returned when items could not be written to disk
+type WriteError struct {
+	File string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("writing %s: %v", e.File, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// returned along with the items of the .bak generation when the data file itself could
+// not be read. The items are usable, but the newest changes may be missing from them.
+type RecoveredError struct {
+	File string
+	// why the data file could not be read
+	Cause error
+}
+
+func (e *RecoveredError) Error() string {
+	return fmt.Sprintf("%v: %s could not be read (%v), loaded %s instead", ErrCorrupt, e.File, e.Cause, backupPath(e.File))
+}
+
+func (e *RecoveredError) Unwrap() error {
+	return ErrCorrupt
+}
+
+// reports whether err only says the items came from the backup, they are still usable
+func IsRecovered(err error) bool {
+	var recovered *RecoveredError
+	return errors.As(err, &recovered)
+}
+
+// reports whether err came from the persistence layer rather than from bad input,
+// so callers can tell "nothing reached disk" apart from "item not found"
+func IsStorageError(err error) bool {
+	var writeErr *WriteError
+	var pathErr *fs.PathError
+	return errors.Is(err, ErrNotExist) || errors.Is(err, ErrCorrupt) || errors.Is(err, ErrLockTimeout) ||
+		errors.As(err, &writeErr) || errors.As(err, &pathErr)
+}
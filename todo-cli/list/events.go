@@ -0,0 +1,64 @@
+package list
+
+// size of each subscriber's event buffer
+const DefaultSubscriberBuffer = 64
+
+// number of recent events the actor keeps so subscribers can resume after a reconnect
+const DefaultEventRetention = 256
+
+type EventType string
+
+const (
+	EventItemAdded   EventType = "item_added"
+	EventItemUpdated EventType = "item_updated"
+	EventItemDeleted EventType = "item_deleted"
+	// the subscriber fell behind and events were dropped, reload the full list
+	EventResync EventType = "resync"
+)
+
+// a committed change to the list. Seq increases by one for every change, so a
+// gap means events were missed. Before is nil for adds, After is nil for deletes.
+type Event struct {
+	Seq    uint64    `json:"seq"`
+	Type   EventType `json:"type"`
+	ID     int       `json:"id"`
+	Before *Item     `json:"before,omitempty"`
+	After  *Item     `json:"after,omitempty"`
+}
+
+// owned by the actor goroutine, never touched from outside it
+type subscriber struct {
+	id     int
+	ch     chan Event
+	missed bool
+}
+
+// delivers without ever blocking the actor. A full buffer drops the event and the
+// subscriber gets a resync marker as soon as it has room again.
+func (s *subscriber) deliver(ev Event) {
+	if s.missed {
+		select {
+		case s.ch <- Event{Seq: ev.Seq, Type: EventResync}:
+			s.missed = false
+		default:
+			return
+		}
+	}
+	select {
+	case s.ch <- ev:
+	default:
+		s.missed = true
+	}
+}
+
+// builds the event for a mutation from the item before and after it
+func newEvent(before, after *Item) Event {
+	switch {
+	case before == nil && after != nil:
+		return Event{Type: EventItemAdded, ID: after.ID, After: after}
+	case before != nil && after == nil:
+		return Event{Type: EventItemDeleted, ID: before.ID, Before: before}
+	default:
+		return Event{Type: EventItemUpdated, ID: after.ID, Before: before, After: after}
+	}
+}
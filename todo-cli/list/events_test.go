@@ -0,0 +1,227 @@
+package list
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		require.True(t, ok, "event channel closed")
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestListActor_SubscribeReceivesChanges(t *testing.T) {
+	ctx := context.Background()
+	actor := NewListActor([]Item{})
+	defer actor.Stop()
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	_, err = actor.Add(ctx, "Task A")
+	require.NoError(t, err)
+	_, err = actor.UpdateStatus(ctx, 0, StatusStarted)
+	require.NoError(t, err)
+	_, err = actor.Delete(ctx, 0)
+	require.NoError(t, err)
+	//failed mutations publish nothing
+	_, err = actor.UpdateStatus(ctx, 42, StatusStarted)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = actor.Delete(ctx, 42)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	added := nextEvent(t, events)
+	assert.Equal(t, EventItemAdded, added.Type)
+	assert.Equal(t, uint64(1), added.Seq)
+	assert.Nil(t, added.Before)
+	assert.Equal(t, "Task A", added.After.Description)
+
+	updated := nextEvent(t, events)
+	assert.Equal(t, EventItemUpdated, updated.Type)
+	assert.Equal(t, uint64(2), updated.Seq)
+	assert.Equal(t, StatusNotStarted, updated.Before.Status)
+	assert.Equal(t, StatusStarted, updated.After.Status)
+
+	deleted := nextEvent(t, events)
+	assert.Equal(t, EventItemDeleted, deleted.Type)
+	assert.Equal(t, uint64(3), deleted.Seq)
+	assert.Equal(t, 0, deleted.ID)
+	assert.Nil(t, deleted.After)
+
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestListActor_SlowSubscriberGetsResync(t *testing.T) {
+	ctx := context.Background()
+	actor := NewListActor([]Item{})
+	defer actor.Stop()
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	//overflow the buffer without reading
+	for i := 0; i < DefaultSubscriberBuffer+5; i++ {
+		_, err := actor.Add(ctx, "Flood")
+		require.NoError(t, err)
+	}
+	for i := 0; i < DefaultSubscriberBuffer; i++ {
+		assert.Equal(t, uint64(i+1), nextEvent(t, events).Seq)
+	}
+
+	_, err = actor.Add(ctx, "After catching up")
+	require.NoError(t, err)
+
+	resync := nextEvent(t, events)
+	assert.Equal(t, EventResync, resync.Type)
+	ev := nextEvent(t, events)
+	assert.Equal(t, EventItemAdded, ev.Type)
+	assert.Equal(t, uint64(DefaultSubscriberBuffer+6), ev.Seq)
+}
+
+func TestListActor_SubscriptionEnds(t *testing.T) {
+	actor := NewListActor([]Item{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+	stopped, err := actor.Subscribe(context.Background())
+	require.NoError(t, err)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-cancelled
+		return !ok
+	}, time.Second, 5*time.Millisecond, "cancelled subscription should be closed")
+
+	actor.Stop()
+	_, ok := <-stopped
+	assert.False(t, ok, "Stop should close every subscription")
+}
+
+func TestListActor_UnsavedChangesPublishNothing(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil), fail: true}
+	actor, err := NewPersistentListActor(store, 0)
+	require.NoError(t, err)
+	defer actor.Stop()
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	_, err = actor.Add(ctx, "Not saved")
+	require.Error(t, err)
+
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// a merge touching several items is one commit with one event per item
+func TestListActor_MergeTagsIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore([]Item{
+		{ID: 1, Tags: []string{"be"}},
+		{ID: 2, Tags: []string{"server"}},
+		{ID: 3, Tags: []string{"frontend"}},
+	})}
+	actor, err := NewPersistentListActor(store, 0)
+	require.NoError(t, err)
+	defer actor.Stop()
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	items, err := actor.MergeTags(ctx, "backend", "be", "server")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend"}, items[0].Tags)
+	assert.Equal(t, []string{"backend"}, items[1].Tags)
+	assert.Equal(t, 1, store.saveCount())
+
+	first, second := nextEvent(t, events), nextEvent(t, events)
+	assert.Equal(t, []int{1, 2}, []int{first.ID, second.ID})
+	assert.Equal(t, []string{"be"}, first.Before.Tags)
+	assert.Equal(t, first.Seq+1, second.Seq)
+
+	//a failed save leaves every item with its old tag
+	store.mu.Lock()
+	store.fail = true
+	store.mu.Unlock()
+	_, err = actor.RenameTag(ctx, "backend", "api")
+	require.Error(t, err)
+	items, err = actor.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend"}, items[0].Tags)
+	assert.Equal(t, []string{"backend"}, items[1].Tags)
+}
+
+func TestListActor_DeletePublishesSubtaskChanges(t *testing.T) {
+	ctx := context.Background()
+	actor := NewListActor([]Item{})
+	defer actor.Stop()
+	_, err := actor.Add(ctx, "Launch")
+	require.NoError(t, err)
+	_, err = actor.AddSubtask(ctx, 0, "Design")
+	require.NoError(t, err)
+	_, err = actor.AddSubtask(ctx, 1, "Mockups")
+	require.NoError(t, err)
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	//Design goes, Mockups moves up to Launch
+	_, err = actor.Delete(ctx, 1)
+	require.NoError(t, err)
+	ev := nextEvent(t, events)
+	assert.Equal(t, EventItemDeleted, ev.Type)
+	assert.Equal(t, 1, ev.ID)
+	ev = nextEvent(t, events)
+	assert.Equal(t, EventItemUpdated, ev.Type)
+	assert.True(t, ev.After.IsChildOf(0))
+
+	items, err := actor.Delete(ctx, 0, DeleteCascade)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, 0, nextEvent(t, events).ID)
+	assert.Equal(t, 2, nextEvent(t, events).ID)
+
+	_, err = actor.AddSubtask(ctx, 42, "Orphan")
+	assert.ErrorIs(t, err, ErrInvalidParent)
+}
+
+func TestListActor_CompletingRecurringItemPublishesNext(t *testing.T) {
+	ctx := context.Background()
+	actor := NewListActor([]Item{{ID: 0, Description: "Ops checklist", Status: StatusNotStarted, Repeat: "FREQ=WEEKLY"}})
+	defer actor.Stop()
+
+	events, err := actor.Subscribe(ctx)
+	require.NoError(t, err)
+
+	items, err := actor.UpdateStatus(ctx, 0, StatusCompleted)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+
+	ev := nextEvent(t, events)
+	assert.Equal(t, EventItemUpdated, ev.Type)
+	assert.Equal(t, 0, ev.ID)
+	ev = nextEvent(t, events)
+	assert.Equal(t, EventItemAdded, ev.Type)
+	assert.Equal(t, 1, ev.ID)
+	assert.Equal(t, "FREQ=WEEKLY", ev.After.Repeat)
+}
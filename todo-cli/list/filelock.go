@@ -0,0 +1,67 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// how long a FileStore waits for another process to release the data file by default
+const DefaultLockTimeout = 5 * time.Second
+
+const lockRetryInterval = 10 * time.Millisecond
+
+// returned when the data file stayed locked by another writer for the whole timeout
+var ErrLockTimeout = errors.New("timed out waiting for data file lock")
+
+// advisory lock held on a sidecar file. The data file itself is replaced on every
+// save, so locking it directly would not exclude a writer that opened the new inode.
+type fileLock struct {
+	f *os.File
+}
+
+func lockPath(filename string) string {
+	return filename + ".lock"
+}
+
+// blocks until the lock is held or the timeout expires
+func acquireLock(filename string, exclusive bool, timeout time.Duration) (*fileLock, error) {
+	path := lockPath(filename)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLockFile(f, exclusive)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if ok {
+			return &fileLock{f: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s held by another process for %v", ErrLockTimeout, path, timeout)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// runs fn while holding the sidecar lock of filename, shared or exclusive
+func WithFileLock(filename string, exclusive bool, timeout time.Duration, fn func() error) error {
+	lock, err := acquireLock(filename, exclusive, timeout)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	return fn()
+}
+
+func (l *fileLock) release() error {
+	defer l.f.Close()
+	return unlockFile(l.f)
+}
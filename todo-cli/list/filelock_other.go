@@ -0,0 +1,14 @@
+//go:build !unix
+
+package list
+
+import "os"
+
+// no flock outside unix, the store falls back to its in-process behaviour
+func tryLockFile(f *os.File, exclusive bool) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package list
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// separate FileStore values open their own lock files, just like separate processes
+func TestFileStore_ConcurrentWritersDontLoseUpdates(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 10; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			store := NewFileStore(file, 10*time.Second)
+			for i := 0; i < 5; i++ {
+				_, err := store.Add(fmt.Sprintf("writer %d task %d", w, i))
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	items, err := NewFileStore(file, 0).Load()
+	require.NoError(t, err)
+	assert.Len(t, items, 50)
+
+	ids := map[int]bool{}
+	for _, item := range items {
+		assert.False(t, ids[item.ID], "duplicate ID %d", item.ID)
+		ids[item.ID] = true
+	}
+}
+
+func TestFileStore_LockTimeout(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	held, err := acquireLock(file, true, time.Second)
+	require.NoError(t, err)
+	defer held.release()
+
+	store := NewFileStore(file, 50*time.Millisecond)
+	_, err = store.Add("blocked")
+	assert.ErrorIs(t, err, ErrLockTimeout)
+	assert.True(t, IsStorageError(err))
+
+	_, err = store.Load()
+	assert.ErrorIs(t, err, ErrLockTimeout, "readers wait for an exclusive writer too")
+}
@@ -0,0 +1,377 @@
+package list
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+)
+
+// number of journal records after which the store writes a snapshot by default
+const DefaultCompactEvery = 100
+
+const (
+	opAdd          = "add"
+	opUpdateDesc   = "update_description"
+	opUpdateStatus = "update_status"
+	opDelete       = "delete"
+)
+
+// one line of the journal. Op, ID and Value keep the intent of the mutation,
+// Item is the resulting state so replaying never has to re-run the list logic.
+type journalRecord struct {
+	Seq   uint64 `json:"seq"`
+	Op    string `json:"op"`
+	ID    int    `json:"id"`
+	Value string `json:"value,omitempty"`
+	Item  *Item  `json:"item,omitempty"`
+	// items the mutation created or changed besides Item, e.g. the next occurrence of a
+	// recurring item or the subtasks of a deleted one
+	Added []Item `json:"added,omitempty"`
+	// IDs of the items the mutation removed besides the deleted one
+	Removed []int `json:"removed,omitempty"`
+}
+
+// write-ahead journal backend: a JSON snapshot plus an append-only log of mutations.
+// The state is rebuilt on open by replaying the log over the snapshot, and every
+// compactEvery records the snapshot is rewritten and the log truncated. The state lives
+// in memory between writes, so the store holds the data file lock until it is closed
+// and one process at a time has the journal.
+type JournalStore struct {
+	mu           sync.Mutex
+	filename     string
+	lock         *fileLock
+	journal      *os.File
+	items        []Item
+	seq          uint64
+	pending      int
+	compactEvery int
+	// set when the snapshot was recovered from its backup, until the next compaction rewrites it
+	recovered error
+}
+
+func journalPath(filename string) string {
+	return filename + ".journal"
+}
+
+// opens (or creates) the snapshot at filename and its journal, replaying any records.
+// compactEvery <= 0 uses DefaultCompactEvery. A journal another process has open is
+// ErrLockTimeout right away.
+func OpenJournalStore(filename string, compactEvery int) (*JournalStore, error) {
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactEvery
+	}
+
+	lock, err := acquireLock(filename, true, 0)
+	if err != nil {
+		return nil, fmt.Errorf("journal of %s is in use: %w", filename, err)
+	}
+	s, err := replayJournal(filename, compactEvery)
+	if err != nil {
+		lock.release()
+		return nil, err
+	}
+	s.lock = lock
+	return s, nil
+}
+
+// reads the snapshot and replays the journal over it, the caller holds the lock
+func replayJournal(filename string, compactEvery int) (*JournalStore, error) {
+	items, loadErr := LoadFromFile(filename)
+	if loadErr != nil && !errors.Is(loadErr, ErrNotExist) && !IsRecovered(loadErr) {
+		return nil, loadErr
+	}
+
+	s := &JournalStore{filename: filename, items: items, compactEvery: compactEvery}
+	if IsRecovered(loadErr) {
+		s.recovered = loadErr
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(journalPath(filename), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, &WriteError{File: journalPath(filename), Err: err}
+	}
+	s.journal = f
+	//a new list gets its snapshot right away, it is what tells the list exists
+	if errors.Is(loadErr, ErrNotExist) {
+		if err := s.compact(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	slog.Info("Journal store opened", "file", filename, "count", len(s.items), "pending", s.pending)
+	return s, nil
+}
+
+// applies every complete record of the journal to the snapshot state. A torn final
+// record (crash mid-append) is skipped and cut off so new records start on a clean line.
+func (s *JournalStore) replay() error {
+	path := journalPath(s.filename)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	for offset < len(data) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			return s.dropTornTail(path, offset, len(data))
+		}
+		line := data[offset : offset+end]
+
+		var rec journalRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if offset+end+1 == len(data) {
+				return s.dropTornTail(path, offset, len(data))
+			}
+			return fmt.Errorf("%w: %s: record at byte %d: %v", ErrCorrupt, path, offset, err)
+		}
+		s.apply(rec)
+		offset += end + 1
+	}
+	return nil
+}
+
+func (s *JournalStore) dropTornTail(path string, offset, size int) error {
+	slog.Warn("Skipping torn journal record", "file", path, "offset", offset, "bytes", size-offset)
+	if err := os.Truncate(path, int64(offset)); err != nil {
+		return &WriteError{File: path, Err: err}
+	}
+	return nil
+}
+
+// replays one record. Records carry the resulting item, so applying a record
+// that is already part of the snapshot is harmless.
+func (s *JournalStore) apply(rec journalRecord) {
+	if rec.Seq > s.seq {
+		s.seq = rec.Seq
+	}
+	s.pending++
+
+	switch {
+	case rec.Op == opDelete:
+		s.items = Delete(s.items, rec.ID)
+	case rec.Item != nil:
+		s.items = putItem(s.items, *rec.Item)
+	}
+	if len(rec.Removed) > 0 {
+		s.items = slices.DeleteFunc(s.items, func(item Item) bool { return slices.Contains(rec.Removed, item.ID) })
+	}
+	for _, item := range rec.Added {
+		s.items = putItem(s.items, item)
+	}
+}
+
+// replaces the item with the same ID, or appends it
+func putItem(items []Item, item Item) []Item {
+	for i := range items {
+		if items[i].ID == item.ID {
+			items[i] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// writes records to the journal in one write and one sync, all or none of them
+func (s *JournalStore) append(recs ...journalRecord) error {
+	var data []byte
+	seq := s.seq
+	for _, rec := range recs {
+		seq++
+		rec.Seq = seq
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return &WriteError{File: s.journal.Name(), Err: err}
+		}
+		data = append(append(data, line...), '\n')
+	}
+	info, err := s.journal.Stat()
+	if err != nil {
+		return &WriteError{File: s.journal.Name(), Err: err}
+	}
+	_, err = s.journal.Write(data)
+	if err == nil {
+		err = syncFile(s.journal)
+	}
+	if err != nil {
+		//cut the half written records off so the next append starts on a clean line
+		s.journal.Truncate(info.Size())
+		return &WriteError{File: s.journal.Name(), Err: err}
+	}
+
+	s.seq = seq
+	s.pending += len(recs)
+	return nil
+}
+
+// runs fn on a copy of the state and journals the outcome before making it visible
+func (s *JournalStore) update(op string, id int, value string, fn func([]Item) ([]Item, int, error)) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated, id, err := fn(append([]Item{}, s.items...))
+	if err != nil {
+		return append([]Item{}, s.items...), err
+	}
+
+	rec := journalRecord{Op: op, ID: id, Value: value}
+	if item, ok := FindItem(updated, id); ok && op != opDelete {
+		rec.Item = &item
+	}
+	for _, item := range updated[min(len(s.items), len(updated)):] {
+		if item.ID != id {
+			rec.Added = append(rec.Added, item)
+		}
+	}
+	if err := s.append(rec); err != nil {
+		return append([]Item{}, s.items...), err
+	}
+
+	s.items = updated
+	s.maybeCompact()
+	return append([]Item{}, s.items...), nil
+}
+
+// compacts once enough records piled up
+func (s *JournalStore) maybeCompact() {
+	if s.pending < s.compactEvery {
+		return
+	}
+	if err := s.compact(); err != nil {
+		//the records are durable in the journal, compaction will be retried next time
+		slog.Error("Journal compaction failed", "file", s.filename, "error", err)
+	}
+}
+
+func (s *JournalStore) Load() ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Item{}, s.items...), s.recovered
+}
+
+// replaces the whole list, which for the journal means writing a fresh snapshot
+func (s *JournalStore) Save(items []Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.items
+	s.items = append([]Item{}, items...)
+	if err := s.compact(); err != nil {
+		s.items = prev
+		return err
+	}
+	return nil
+}
+
+// journals one record per change, the store is the only writer so nothing needs redoing
+func (s *JournalStore) Apply(changes []Change) ([]Item, []Redone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := append([]Item{}, s.items...)
+	recs := make([]journalRecord, 0, len(changes))
+	for _, c := range changes {
+		recs = append(recs, changeRecord(c))
+		items = c.applyTo(items)
+	}
+	if err := s.append(recs...); err != nil {
+		return nil, nil, err
+	}
+	s.items = items
+	s.maybeCompact()
+	return nil, nil, nil
+}
+
+// the journal record of a change: the item it is about as Item, everything else it
+// touched in Added and Removed
+func changeRecord(c Change) journalRecord {
+	rec := journalRecord{Op: c.Op, ID: c.ID, Value: c.Value}
+	for _, item := range c.Put {
+		if item.ID == c.ID && rec.Item == nil && c.Op != opDelete {
+			rec.Item = &item
+			continue
+		}
+		rec.Added = append(rec.Added, item)
+	}
+	for _, id := range c.Remove {
+		if id != c.ID || c.Op != opDelete {
+			rec.Removed = append(rec.Removed, id)
+		}
+	}
+	return rec
+}
+
+func (s *JournalStore) Add(description string) ([]Item, error) {
+	return s.update(opAdd, 0, description, func(items []Item) ([]Item, int, error) {
+		items = Add(items, description)
+		return items, items[len(items)-1].ID, nil
+	})
+}
+
+func (s *JournalStore) UpdateDescription(id int, desc string) ([]Item, error) {
+	return s.update(opUpdateDesc, id, desc, func(items []Item) ([]Item, int, error) {
+		items, err := UpdateDescription(items, id, desc)
+		return items, id, err
+	})
+}
+
+func (s *JournalStore) UpdateStatus(id int, status string) ([]Item, error) {
+	return s.update(opUpdateStatus, id, status, func(items []Item) ([]Item, int, error) {
+		items, err := UpdateStatus(items, id, status)
+		return items, id, err
+	})
+}
+
+func (s *JournalStore) Delete(id int) ([]Item, error) {
+	return s.update(opDelete, id, "", func(items []Item) ([]Item, int, error) {
+		return Delete(items, id), id, nil
+	})
+}
+
+// writes the current state as the snapshot and truncates the journal
+func (s *JournalStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *JournalStore) compact() error {
+	if err := SaveToFile(s.filename, s.items); err != nil {
+		return err
+	}
+	//a crash before this truncate only means the records get replayed again on open
+	if err := s.journal.Truncate(0); err != nil {
+		return &WriteError{File: s.journal.Name(), Err: err}
+	}
+	if err := syncFile(s.journal); err != nil {
+		return &WriteError{File: s.journal.Name(), Err: err}
+	}
+	slog.Info("Journal compacted", "file", s.filename, "records", s.pending, "seq", s.seq)
+	s.pending = 0
+	s.recovered = nil
+	return nil
+}
+
+// closes the journal and lets other processes open it
+func (s *JournalStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.journal.Close()
+	if s.lock != nil {
+		s.lock.release()
+		s.lock = nil
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package list
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openJournal(t *testing.T, file string, compactEvery int) *JournalStore {
+	s, err := OpenJournalStore(file, compactEvery)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func journalLines(t *testing.T, file string) []string {
+	data, err := os.ReadFile(journalPath(file))
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestJournalStore_ReplayOnOpen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	s := openJournal(t, file, 100)
+
+	_, err := s.Add("Task A")
+	require.NoError(t, err)
+	_, err = s.Add("Task B")
+	require.NoError(t, err)
+	_, err = s.UpdateStatus(0, StatusCompleted)
+	require.NoError(t, err)
+	_, err = s.UpdateDescription(1, "Task B2")
+	require.NoError(t, err)
+	_, err = s.Add("Task C")
+	require.NoError(t, err)
+	_, err = s.Delete(2)
+	require.NoError(t, err)
+	want, _ := s.Load()
+	require.NoError(t, s.Close())
+
+	lines := journalLines(t, file)
+	assert.Len(t, lines, 6, "every mutation should be one journal record")
+	assert.Contains(t, lines[2], `"op":"update_status"`)
+
+	reopened := openJournal(t, file, 100)
+	got, err := reopened.Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestJournalStore_Compaction(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	s := openJournal(t, file, 3)
+
+	for _, desc := range []string{"A", "B", "C", "D"} {
+		_, err := s.Add(desc)
+		require.NoError(t, err)
+	}
+
+	//the third record triggered a snapshot, only the fourth is left in the journal
+	snapshot, err := LoadFromFile(file)
+	require.NoError(t, err)
+	assert.Len(t, snapshot, 3)
+	assert.Len(t, journalLines(t, file), 1)
+
+	require.NoError(t, s.Close())
+	reopened := openJournal(t, file, 3)
+	items, _ := reopened.Load()
+	assert.Len(t, items, 4)
+}
+
+// replaying records that are already in the snapshot (crash between snapshot and truncate)
+func TestJournalStore_ReplayIsIdempotent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	s := openJournal(t, file, 100)
+	_, err := s.Add("A")
+	require.NoError(t, err)
+	_, err = s.Add("B")
+	require.NoError(t, err)
+	_, err = s.Delete(0)
+	require.NoError(t, err)
+	want, _ := s.Load()
+	require.NoError(t, SaveToFile(file, want))
+	require.NoError(t, s.Close())
+
+	reopened := openJournal(t, file, 100)
+	got, _ := reopened.Load()
+	assert.Equal(t, want, got)
+}
+
+func TestJournalStore_SkipsTornFinalRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	s := openJournal(t, file, 100)
+	_, err := s.Add("A")
+	require.NoError(t, err)
+	_, err = s.Add("B")
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	//crash in the middle of appending the third record
+	f, err := os.OpenFile(journalPath(file), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":3,"op":"add","id":2,"value":"C","item":{"id":2,"desc`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened := openJournal(t, file, 100)
+	items, _ := reopened.Load()
+	assert.Len(t, items, 2)
+
+	//new records must land on a clean line after the torn one was dropped
+	_, err = reopened.Add("C")
+	require.NoError(t, err)
+	require.NoError(t, reopened.Close())
+
+	again := openJournal(t, file, 100)
+	items, _ = again.Load()
+	assert.Len(t, items, 3)
+	assert.Equal(t, "C", items[2].Description)
+}
+
+func TestJournalStore_CorruptRecordInTheMiddle(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	journal := "{not json}\n" + `{"seq":2,"op":"delete","id":0}` + "\n"
+	require.NoError(t, os.WriteFile(journalPath(file), []byte(journal), 0644))
+
+	_, err := OpenJournalStore(file, 100)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestJournalStore_ReplaysNextOccurrence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	require.NoError(t, SaveToFile(file, []Item{{ID: 0, Description: "Ops checklist", Status: StatusNotStarted,
+		DueAt: time.Date(2026, 10, 5, 18, 0, 0, 0, time.UTC), Repeat: "FREQ=DAILY"}}))
+	s := openJournal(t, file, 100)
+
+	_, err := s.UpdateStatus(0, StatusCompleted)
+	require.NoError(t, err)
+	want, _ := s.Load()
+	require.Len(t, want, 2)
+	require.NoError(t, s.Close())
+
+	lines := journalLines(t, file)
+	assert.Len(t, lines, 1, "the next occurrence is part of the status record")
+
+	reopened := openJournal(t, file, 100)
+	got, err := reopened.Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+// the state lives in memory, so a second process must not append to or compact the journal
+func TestJournalStore_OneProcessAtATime(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	s := openJournal(t, file, 100)
+	_, err := s.Add("Mine")
+	require.NoError(t, err)
+
+	_, err = OpenJournalStore(file, 100)
+	assert.ErrorIs(t, err, ErrLockTimeout)
+
+	require.NoError(t, s.Close())
+	items, err := openJournal(t, file, 100).Load()
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
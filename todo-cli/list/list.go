@@ -2,10 +2,13 @@ package list
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"time"
 )
 
 const DefaultDataFile = "items.json"
@@ -16,29 +19,76 @@ const (
 	StatusCompleted  = "completed"
 )
 
-// the to-do list structure
+// the to-do list structure. Timestamps are maintained by Add, UpdateDescription and
+// UpdateStatus, they are zero (and omitted) for items saved before they existed.
 type Item struct {
-	ID          int    `json:"id"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	ID          int       `json:"id"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+	StartedAt   time.Time `json:"started_at,omitzero"`
+	CompletedAt time.Time `json:"completed_at,omitzero"`
+	DueAt       time.Time `json:"due_at,omitzero"`
+	Priority    string    `json:"priority,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	// the item this is a subtask of, nil for top level items. Never changed in place,
+	// copies of an item share the pointer.
+	ParentID *int `json:"parent_id,omitempty"`
+	// IDs of the items that have to be completed before this one can start, sorted.
+	// Like ParentID never changed in place.
+	BlockedBy []int `json:"blocked_by,omitempty"`
+	// recurrence rule (see Rule), completing the item adds the next occurrence
+	Repeat string `json:"repeat,omitempty"`
 }
 
-func LoadFromFile(filename string) []Item {
+func readItems(filename string) ([]Item, error) {
 	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotExist, filename)
+	}
 	if err != nil {
-		slog.Warn("No existing data file found, starting with emplty list", "file", filename, "error", err)
-		return []Item{}
+		return nil, err
 	}
 
 	var items []Item
 
 	if err := json.Unmarshal(data, &items); err != nil {
-		slog.Error("Error loading items", "file", filename, "error", err)
-		return []Item{}
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, filename, err)
+	}
+	return items, nil
+}
+
+// Loads items from the JSON file, falling back to the .bak generation when the primary is unreadable.
+// A missing file returns an empty list together with ErrNotExist, an unreadable one returns ErrCorrupt.
+// Items loaded from the backup come with a *RecoveredError, which also matches ErrCorrupt.
+func LoadFromFile(filename string) ([]Item, error) {
+	items, err := readItems(filename)
+	if err == nil {
+		slog.Info("Items loaded from file", "file", filename, "count", len(items))
+		return items, nil
+	}
+
+	if backup, bakErr := readItems(backupPath(filename)); bakErr == nil {
+		slog.Warn("Data file unreadable, loaded backup instead", "file", filename, "error", err, "count", len(backup))
+		return backup, &RecoveredError{File: filename, Cause: err}
 	}
 
-	slog.Info("Items loaded from file", "file", filename, "count", len(items))
-	return items
+	if errors.Is(err, ErrNotExist) {
+		return []Item{}, err
+	}
+	return nil, err
+}
+
+// the item with id, false when the list has none
+func FindItem(items []Item, id int) (Item, bool) {
+	for _, item := range items {
+		if item.ID == id {
+			return item, true
+		}
+	}
+	return Item{}, false
 }
 
 func GetNextID(items []Item) int {
@@ -52,43 +102,63 @@ func GetNextID(items []Item) int {
 }
 
 // Saves items to JSON file
-func SaveToFile(filename string, items []Item) {
+func SaveToFile(filename string, items []Item) error {
 
 	data, err := json.MarshalIndent(items, "", " ")
 	if err != nil {
-		slog.Error("Error marshlling items for save", "file", filename, "error", err)
-		return
+		return &WriteError{File: filename, Err: err}
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
-		slog.Error("Error writing items for file", "file", filename, "error", err)
-		return
+	if err := WriteFileAtomic(filename, data, 0644); err != nil {
+		return &WriteError{File: filename, Err: err}
 	}
 
 	slog.Info("Items save successfully", "file", filename, "count", len(items))
+	return nil
 }
 
 func Add(items []Item, description string) []Item {
 
+	created := timestamp()
 	newItem := Item{
 		ID:          GetNextID(items),
 		Description: description,
-		Status:      StatusNotStarted,
+		Status:      workflow.Initial(),
+		CreatedAt:   created,
+		UpdatedAt:   created,
 	}
 	slog.Info("Items added", "id", newItem.ID, "description", newItem.Description)
 	return append(items, newItem)
 }
 
-func Delete(items []Item, id int) []Item {
+// removes the item with id. Its subtasks move up to its parent, or with
+// DeleteCascade are deleted along with it. Items waiting on a deleted item stop waiting.
+func Delete(items []Item, id int, mode ...DeleteMode) []Item {
+	removed := map[int]bool{id: true}
+	if len(mode) > 0 && mode[0] == DeleteCascade {
+		for _, child := range descendants(items, id) {
+			removed[child] = true
+		}
+	}
+	deleted, found := FindItem(items, id)
+
 	newItems := []Item{}
-	found := false
 	for _, item := range items {
-		if item.ID != id {
-			newItems = append(newItems, item)
-		} else {
-			found = true
-			slog.Info("Item deleted", "id", id)
+		if removed[item.ID] {
+			slog.Info("Item deleted", "id", item.ID)
+			continue
+		}
+		//no new UpdatedAt, so replaying a journaled delete gives the same result
+		if item.ParentID != nil && *item.ParentID == id {
+			item.ParentID = deleted.ParentID
 		}
+		if slices.ContainsFunc(item.BlockedBy, func(b int) bool { return removed[b] }) {
+			item.BlockedBy = slices.DeleteFunc(slices.Clone(item.BlockedBy), func(b int) bool { return removed[b] })
+			if len(item.BlockedBy) == 0 {
+				item.BlockedBy = nil
+			}
+		}
+		newItems = append(newItems, item)
 	}
 
 	if !found {
@@ -102,31 +172,197 @@ func UpdateDescription(items []Item, id int, desc string) ([]Item, error) {
 	for i, item := range items {
 		if item.ID == id {
 			items[i].Description = desc
+			items[i].UpdatedAt = timestamp()
 			slog.Info("Item description updated", "id", id, "new_description", desc)
 			return items, nil
 		}
 	}
 	slog.Warn("Update description failed: item not found", "id", id)
-	return items, fmt.Errorf("item with ID %d not found", id)
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// replaces the free-form notes of the item with id
+func SetNotes(items []Item, id int, notes string) ([]Item, error) {
+	for i, item := range items {
+		if item.ID == id {
+			items[i].Notes = notes
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
 }
 
+// changes the status of the item with id, following the workflow (see SetWorkflow).
+// Items can't move into an active or done status while any item blocking them is
+// still open, see OverrideStatus. Finishing a recurring item appends its next occurrence.
 func UpdateStatus(items []Item, id int, status string) ([]Item, error) {
+	return updateStatus(items, id, status, false)
+}
+
+// like UpdateStatus, but ignores open blockers
+func OverrideStatus(items []Item, id int, status string) ([]Item, error) {
+	return updateStatus(items, id, status, true)
+}
 
-	status = strings.ToLower(status)
+func updateStatus(items []Item, id int, status string, override bool) ([]Item, error) {
+
+	status = strings.ToLower(strings.TrimSpace(status))
 
 	for i, item := range items {
 		if item.ID == id {
-			switch status {
-			case StatusStarted, StatusCompleted, StatusNotStarted:
-				items[i].Status = status
-				slog.Info("Item status updated", "id", id, "new_status", status)
-				return items, nil
-			default:
+			if !slices.Contains(workflow.Statuses, status) {
 				slog.Warn("Invalid status value", "status", status)
-				return items, fmt.Errorf("invalid satus: %s. Please use Started, Not Started or Completed", status)
+				return items, fmt.Errorf("%w: %s. Please use one of %s", ErrInvalidStatus, status, quoteAll(workflow.Statuses))
+			}
+			if !workflow.Allows(item.Status, status) {
+				slog.Warn("Status transition not allowed", "id", id, "from", item.Status, "to", status)
+				return items, &TransitionError{ID: id, From: item.Status, To: status, Allowed: workflow.Next(item.Status)}
 			}
+			working := workflow.IsActive(status) || workflow.IsDone(status)
+			if open := OpenBlockers(items, id); !override && working && len(open) > 0 {
+				slog.Warn("Item is blocked", "id", id, "blocked_by", open)
+				return items, fmt.Errorf("%w: item %d is waiting on %s, complete them first or override",
+					ErrBlocked, id, formatIDs(open))
+			}
+			finished := workflow.IsDone(status) && !item.IsDone()
+			setStatus(&items[i], status, timestamp())
+			slog.Info("Item status updated", "id", id, "new_status", status)
+			if finished && item.Repeat != "" {
+				items = spawnNext(items, i)
+			}
+			return items, nil
 		}
 	}
 	slog.Warn("Update status failed: item not found", "id", id)
-	return items, fmt.Errorf("item with ID %d not found", id)
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// changes the status and keeps the started/completed times in line with it.
+// Moving back to the initial status clears both, any status that isn't done clears
+// the completed time.
+func setStatus(item *Item, status string, at time.Time) {
+	item.UpdatedAt = at
+	if item.Status == status {
+		return
+	}
+	item.Status = status
+	switch {
+	case status == workflow.Initial():
+		item.StartedAt = time.Time{}
+		item.CompletedAt = time.Time{}
+	case workflow.IsActive(status):
+		if item.StartedAt.IsZero() {
+			item.StartedAt = at
+		}
+		item.CompletedAt = time.Time{}
+	case workflow.IsDone(status):
+		item.CompletedAt = at
+	default:
+		item.CompletedAt = time.Time{}
+	}
+}
+
+// ItemPatch holds the fields to change on one item, nil fields are left alone
+type ItemPatch struct {
+	Description *string `json:"description,omitempty"`
+	Status      *string `json:"status,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
+	// moves the item under another one, a negative ID makes it a top level item
+	ParentID *int `json:"parent_id,omitempty"`
+	// blockers to add or remove, applied before Status
+	AddBlockedBy    []int `json:"add_blocked_by,omitempty"`
+	RemoveBlockedBy []int `json:"remove_blocked_by,omitempty"`
+	// an RRULE (see ParseRule), empty or none stops the item repeating. Applied before
+	// Status, so one patch can set a rule and complete the item.
+	Repeat *string `json:"repeat,omitempty"`
+	// lets Status start or complete an item that still has open blockers
+	Force bool `json:"force,omitempty"`
+	// a date or RFC 3339 time (see ParseDue), empty clears it
+	Due *string `json:"due,omitempty"`
+	// P0 to P3, empty clears it
+	Priority *string `json:"priority,omitempty"`
+	// replaces all tags, applied before AddTags and RemoveTags
+	Tags       *[]string `json:"tags,omitempty"`
+	AddTags    []string  `json:"add_tags,omitempty"`
+	RemoveTags []string  `json:"remove_tags,omitempty"`
+}
+
+func (p ItemPatch) IsEmpty() bool {
+	return p.Description == nil && p.Status == nil && p.Notes == nil && p.ParentID == nil &&
+		len(p.AddBlockedBy) == 0 && len(p.RemoveBlockedBy) == 0 && p.Repeat == nil && p.Due == nil && p.Priority == nil &&
+		p.Tags == nil && len(p.AddTags) == 0 && len(p.RemoveTags) == 0
+}
+
+// applies every field of patch to the item with id, or none of them when one is invalid
+func Patch(items []Item, id int, patch ItemPatch) ([]Item, error) {
+	updated := append([]Item{}, items...)
+	var err error
+	if patch.Description != nil {
+		if updated, err = UpdateDescription(updated, id, *patch.Description); err != nil {
+			return items, err
+		}
+	}
+	for _, blocker := range patch.AddBlockedBy {
+		if updated, err = AddBlocker(updated, id, blocker); err != nil {
+			return items, err
+		}
+	}
+	for _, blocker := range patch.RemoveBlockedBy {
+		if updated, err = RemoveBlocker(updated, id, blocker); err != nil {
+			return items, err
+		}
+	}
+	if patch.Repeat != nil {
+		if updated, err = SetRepeat(updated, id, *patch.Repeat); err != nil {
+			return items, err
+		}
+	}
+	if patch.Status != nil {
+		if updated, err = updateStatus(updated, id, *patch.Status, patch.Force); err != nil {
+			return items, err
+		}
+	}
+	if patch.Notes != nil {
+		if updated, err = SetNotes(updated, id, *patch.Notes); err != nil {
+			return items, err
+		}
+	}
+	if patch.ParentID != nil {
+		var parent *int
+		if *patch.ParentID >= 0 {
+			parent = patch.ParentID
+		}
+		if updated, err = SetParent(updated, id, parent); err != nil {
+			return items, err
+		}
+	}
+	if patch.Due != nil {
+		due, err := ParseDue(*patch.Due, time.Local)
+		if err != nil {
+			return items, err
+		}
+		if updated, err = SetDue(updated, id, due); err != nil {
+			return items, err
+		}
+	}
+	if patch.Priority != nil {
+		if updated, err = SetPriority(updated, id, *patch.Priority); err != nil {
+			return items, err
+		}
+	}
+	if patch.Tags != nil {
+		if updated, err = SetTags(updated, id, *patch.Tags); err != nil {
+			return items, err
+		}
+	}
+	if len(patch.AddTags) > 0 || len(patch.RemoveTags) > 0 {
+		if updated, err = TagItem(updated, id, patch.AddTags, patch.RemoveTags); err != nil {
+			return items, err
+		}
+	}
+	if _, ok := FindItem(updated, id); !ok {
+		return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	return updated, nil
 }
@@ -1,8 +1,11 @@
 package list_test
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 	"todo-cli/list"
 )
 
@@ -97,9 +100,14 @@ func TestSaveAndLoad(t *testing.T) {
 	defer os.Remove(tmpFile)
 
 	items := sampleItems()
-	list.SaveToFile(tmpFile, items)
+	if err := list.SaveToFile(tmpFile, items); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
 
-	loaded := list.LoadFromFile(tmpFile)
+	loaded, err := list.LoadFromFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
 	if len(loaded) != len(items) {
 		t.Errorf("Expected %d items, got %d", len(items), len(loaded))
 	}
@@ -110,3 +118,118 @@ func TestSaveAndLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadFromFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	items, err := list.LoadFromFile(filepath.Join(dir, "missing.json"))
+	if !errors.Is(err, list.ErrNotExist) {
+		t.Errorf("Expected ErrNotExist, got %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("Expected empty list, got %d items", len(items))
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`[{"id": 1,`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.LoadFromFile(corrupt); !errors.Is(err, list.ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestSaveToFile_WriteError(t *testing.T) {
+	err := list.SaveToFile(filepath.Join(t.TempDir(), "missing-dir", "items.json"), sampleItems())
+
+	var writeErr *list.WriteError
+	if !errors.As(err, &writeErr) {
+		t.Fatalf("Expected WriteError, got %v", err)
+	}
+	if !list.IsStorageError(err) {
+		t.Errorf("Expected a storage error")
+	}
+}
+
+func TestPatch(t *testing.T) {
+	desc, status := "Patched", list.StatusCompleted
+	updated, err := list.Patch(sampleItems(), 1, list.ItemPatch{Description: &desc, Status: &status})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if updated[0].Description != "Patched" || updated[0].Status != list.StatusCompleted {
+		t.Errorf("Patch not applied, got %+v", updated[0])
+	}
+
+	//one invalid field rejects the whole patch
+	items := sampleItems()
+	bad := "sleeping"
+	unchanged, err := list.Patch(items, 1, list.ItemPatch{Description: &desc, Status: &bad})
+	if err == nil {
+		t.Fatalf("Expected error for invalid status, got nil")
+	}
+	if unchanged[0].Description != "Task 1" || items[0].Description != "Task 1" {
+		t.Errorf("Rejected patch should change nothing, got %+v", unchanged[0])
+	}
+
+	if _, err := list.Patch(items, 999, list.ItemPatch{Status: &status}); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	clock := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	restore := list.SetClock(func() time.Time { return clock })
+	defer restore()
+
+	items := list.Add(nil, "Timed")
+	item := items[0]
+	if !item.CreatedAt.Equal(clock) || !item.UpdatedAt.Equal(clock) {
+		t.Errorf("Expected created/updated %v, got %v/%v", clock, item.CreatedAt, item.UpdatedAt)
+	}
+
+	clock = clock.Add(time.Hour)
+	items, _ = list.UpdateDescription(items, 0, "Renamed")
+	if !items[0].UpdatedAt.Equal(clock) || !items[0].CreatedAt.Equal(clock.Add(-time.Hour)) {
+		t.Errorf("Expected only updated to move, got %+v", items[0])
+	}
+
+	clock = clock.Add(time.Hour)
+	items, _ = list.UpdateStatus(items, 0, list.StatusStarted)
+	started := clock
+	if !items[0].StartedAt.Equal(started) || !items[0].CompletedAt.IsZero() {
+		t.Errorf("Expected started at %v, got %+v", started, items[0])
+	}
+
+	clock = clock.Add(time.Hour)
+	items, _ = list.UpdateStatus(items, 0, list.StatusCompleted)
+	if !items[0].CompletedAt.Equal(clock) || !items[0].StartedAt.Equal(started) {
+		t.Errorf("Expected completed at %v, got %+v", clock, items[0])
+	}
+
+	//reopening clears the completion time
+	items, _ = list.UpdateStatus(items, 0, list.StatusNotStarted)
+	if !items[0].StartedAt.IsZero() || !items[0].CompletedAt.IsZero() {
+		t.Errorf("Expected started/completed to be cleared, got %+v", items[0])
+	}
+}
+
+// files written before timestamps existed must still load
+func TestLoadFromFile_WithoutTimestamps(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "items.json")
+	old := `[{"id": 1, "description": "Old item", "status": "started"}]`
+	if err := os.WriteFile(file, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := list.LoadFromFile(file)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(items) != 1 || items[0].Description != "Old item" || !items[0].CreatedAt.IsZero() {
+		t.Errorf("Unexpected items %+v", items)
+	}
+	if list.FormatTime(items[0].CreatedAt) != "-" {
+		t.Errorf("Expected unset time to format as '-'")
+	}
+}
@@ -0,0 +1,252 @@
+package list
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the list every item belongs to unless moved to another one
+const DefaultList = "default"
+
+var listNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// lowercases a list name and checks it is 1 to 32 letters, digits, _ or -
+func NormalizeListName(name string) (string, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if !listNamePattern.MatchString(n) {
+		return "", fmt.Errorf("%w: %q, use up to 32 letters, digits, _ or -", ErrInvalidList, name)
+	}
+	return n, nil
+}
+
+// the data file of a named list. The default list keeps the data file itself, so data
+// saved before there were lists is the default list without any migration. The others
+// go next to it, items.json holds "default", items.work.json holds "work".
+func ListFile(dataFile, name string) string {
+	if name == DefaultList {
+		return dataFile
+	}
+	ext := filepath.Ext(dataFile)
+	return strings.TrimSuffix(dataFile, ext) + "." + name + ext
+}
+
+// names of the lists that have a data file next to dataFile, default first
+func FindLists(dataFile string) ([]string, error) {
+	ext := filepath.Ext(dataFile)
+	prefix := strings.TrimSuffix(dataFile, ext) + "."
+	matches, err := filepath.Glob(globEscape(prefix) + "*" + globEscape(ext))
+	if err != nil {
+		return nil, err
+	}
+	names := []string{DefaultList}
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		if listNamePattern.MatchString(name) && name != DefaultList {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func globEscape(s string) string {
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`).Replace(s)
+}
+
+// Lists keeps one ListActor per named list, each with its own store and ID sequence.
+// Lists are opened on first use. Only Create makes a new one, so reading a list that
+// doesn't exist leaves nothing behind.
+type Lists struct {
+	// names of the lists that exist in storage, nil when only the lists used so far count
+	Discover func() ([]string, error)
+	// called when a list is opened from the backup of its data file, see RecoveredError
+	Recovered func(name string, err error)
+
+	mu          sync.Mutex
+	open        func(name string) (Store, error)
+	groupCommit time.Duration
+	actors      map[string]*ListActor
+	stores      []Store
+}
+
+// open returns the store of a list, called once per list the first time it is used
+func NewLists(open func(name string) (Store, error), groupCommit time.Duration) *Lists {
+	return &Lists{open: open, groupCommit: groupCommit, actors: map[string]*ListActor{}}
+}
+
+// a Lists with only the default list, served by actor. Other names are ErrListNotFound.
+func SingleList(actor *ListActor) *Lists {
+	l := NewLists(nil, 0)
+	l.actors[DefaultList] = actor
+	return l
+}
+
+// the actor of an existing list, starting it when it isn't running yet. The default list
+// always exists, any other has to be in storage (see Discover) or created before,
+// else it is ErrListNotFound.
+func (l *Lists) Get(name string) (*ListActor, error) {
+	return l.get(name, false)
+}
+
+// the actor of the named list, creating the list when it doesn't exist yet
+func (l *Lists) Create(name string) (*ListActor, error) {
+	return l.get(name, true)
+}
+
+func (l *Lists) get(name string, create bool) (*ListActor, error) {
+	name, err := NormalizeListName(name)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if actor, ok := l.actors[name]; ok {
+		return actor, nil
+	}
+	if l.open == nil {
+		return nil, fmt.Errorf("%w: %s", ErrListNotFound, name)
+	}
+	if !create && name != DefaultList {
+		exists, err := l.exists(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrListNotFound, name)
+		}
+	}
+
+	store, err := l.open(name)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := NewPersistentListActor(store, l.groupCommit)
+	if err != nil && !IsRecovered(err) {
+		closeStore(store)
+		return nil, err
+	}
+	if err != nil {
+		slog.Error("List recovered from backup, recent changes may be missing", "list", name, "error", err)
+		if l.Recovered != nil {
+			l.Recovered(name, err)
+		}
+	}
+	l.actors[name] = actor
+	l.stores = append(l.stores, store)
+	slog.Info("List opened", "list", name)
+	return actor, nil
+}
+
+// reports whether storage holds the list, without Discover only running lists exist
+func (l *Lists) exists(name string) (bool, error) {
+	if l.Discover == nil {
+		return false, nil
+	}
+	names, err := l.Discover()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(names, name), nil
+}
+
+// every list in storage or used so far, default first and the rest by name
+func (l *Lists) Names() ([]string, error) {
+	var names []string
+	if l.Discover != nil {
+		found, err := l.Discover()
+		if err != nil {
+			return nil, err
+		}
+		names = found
+	}
+	l.mu.Lock()
+	for name := range l.actors {
+		names = append(names, name)
+	}
+	l.mu.Unlock()
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if i := slices.Index(names, DefaultList); i > 0 {
+		names = slices.Insert(slices.Delete(names, i, i+1), 0, DefaultList)
+	}
+	return names, nil
+}
+
+// moves the item with id from one list to another, where it gets the next ID of that
+// list, creating the target list when it doesn't exist yet. It is added to the target
+// before it leaves the source, so a failure in between can't lose it. Its subtasks stay
+// behind (moving up a level) and it stops waiting on or blocking items of the old list.
+func (l *Lists) Move(ctx context.Context, id int, from, to string) (Item, error) {
+	src, err := l.Get(from)
+	if err != nil {
+		return Item{}, err
+	}
+	dst, err := l.Create(to)
+	if err != nil {
+		return Item{}, err
+	}
+	if src == dst {
+		return Item{}, fmt.Errorf("%w: item %d is already in %s", ErrInvalidList, id, to)
+	}
+
+	items, err := src.GetAll(ctx)
+	if err != nil {
+		return Item{}, err
+	}
+	item, ok := FindItem(items, id)
+	if !ok {
+		return Item{}, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	added, err := dst.Import(ctx, item)
+	if err != nil {
+		return Item{}, err
+	}
+	moved := added[len(added)-1]
+	if _, err := src.Delete(ctx, id); err != nil {
+		//undo the copy, the item stays where it was
+		if _, undoErr := dst.Delete(context.WithoutCancel(ctx), moved.ID); undoErr != nil {
+			slog.Error("Could not undo a failed move", "id", id, "from", from, "to", to, "copy", moved.ID, "error", undoErr)
+		}
+		return Item{}, err
+	}
+	slog.Info("Item moved to another list", "id", id, "from", from, "to", to, "new_id", moved.ID)
+	return moved, nil
+}
+
+// stops every actor and closes the stores Lists opened
+func (l *Lists) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, actor := range l.actors {
+		actor.Stop()
+	}
+	for _, store := range l.stores {
+		closeStore(store)
+	}
+	l.stores = nil
+}
+
+func closeStore(store Store) {
+	if c, ok := store.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// appends a copy of an item from another list with the next ID of this one. Parent and
+// blockers refer to IDs of the other list, so they are dropped.
+func Import(items []Item, item Item) []Item {
+	item.ID = GetNextID(items)
+	item.ParentID = nil
+	item.BlockedBy = nil
+	item.UpdatedAt = timestamp()
+	slog.Info("Item imported", "id", item.ID, "description", item.Description)
+	return append(items, item)
+}
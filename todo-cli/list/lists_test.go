@@ -0,0 +1,163 @@
+package list_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"todo-cli/list"
+)
+
+func memoryLists(t *testing.T) *list.Lists {
+	lists := list.NewLists(func(string) (list.Store, error) { return list.NewMemoryStore(nil), nil }, 0)
+	t.Cleanup(lists.Stop)
+	return lists
+}
+
+func TestListFile(t *testing.T) {
+	if got := list.ListFile("data/items.json", list.DefaultList); got != "data/items.json" {
+		t.Errorf("Expected the default list to keep the data file, got %s", got)
+	}
+	if got := list.ListFile("data/items.json", "work"); got != "data/items.work.json" {
+		t.Errorf("Unexpected file for work: %s", got)
+	}
+
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "items.json")
+	for _, name := range []string{"work", "home"} {
+		if err := list.SaveToFile(list.ListFile(dataFile, name), nil); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	//backups and other files next to the data file are not lists
+	list.SaveToFile(dataFile+".bak", nil)
+	list.SaveToFile(filepath.Join(dir, "items.Not A List.json"), nil)
+
+	names, err := list.FindLists(dataFile)
+	if err != nil || !slices.Equal(names, []string{"default", "home", "work"}) {
+		t.Errorf("Unexpected lists %v (%v)", names, err)
+	}
+}
+
+func TestLists_ExistingDataIsTheDefaultList(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "items.json")
+	if err := list.SaveToFile(dataFile, list.Add(nil, "Saved before lists")); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	lists := list.NewLists(func(name string) (list.Store, error) {
+		return list.NewFileStore(list.ListFile(dataFile, name), list.DefaultLockTimeout), nil
+	}, 0)
+	defer lists.Stop()
+
+	actor, err := lists.Get("Default")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	items, _ := actor.GetAll(context.Background())
+	if len(items) != 1 || items[0].Description != "Saved before lists" {
+		t.Errorf("Expected the existing items in the default list, got %+v", items)
+	}
+}
+
+// reading a list that doesn't exist must not leave a store, file or actor behind
+func TestLists_GetDoesNotCreate(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "items.json")
+	lists := list.NewLists(func(name string) (list.Store, error) {
+		return list.NewFileStore(list.ListFile(dataFile, name), list.DefaultLockTimeout), nil
+	}, 0)
+	lists.Discover = func() ([]string, error) { return list.FindLists(dataFile) }
+	defer lists.Stop()
+
+	if _, err := lists.Get("work"); !errors.Is(err, list.ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+	if names, _ := lists.Names(); !slices.Equal(names, []string{"default"}) {
+		t.Errorf("Expected only the default list, got %v", names)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no files, got %v", entries)
+	}
+
+	work, err := lists.Create("work")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	work.Add(context.Background(), "Standup")
+	if again, err := lists.Get("work"); err != nil || again != work {
+		t.Errorf("Expected the created list, got %v", err)
+	}
+}
+
+func TestLists_SeparateIDs(t *testing.T) {
+	ctx := context.Background()
+	lists := memoryLists(t)
+	home, _ := lists.Get(list.DefaultList)
+	work, err := lists.Create("work")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	home.Add(ctx, "Groceries")
+	items, _ := work.Add(ctx, "Standup")
+	if items[0].ID != 0 {
+		t.Errorf("Expected every list to number its items from 0, got %d", items[0].ID)
+	}
+	if again, _ := lists.Get("WORK"); again != work {
+		t.Errorf("Expected list names to be case insensitive")
+	}
+
+	if _, err := lists.Get("no spaces"); !errors.Is(err, list.ErrInvalidList) {
+		t.Errorf("Expected ErrInvalidList, got %v", err)
+	}
+	names, _ := lists.Names()
+	if !slices.Equal(names, []string{"default", "work"}) {
+		t.Errorf("Unexpected lists %v", names)
+	}
+
+	single := list.SingleList(list.NewListActor(nil))
+	defer single.Stop()
+	if _, err := single.Get("work"); !errors.Is(err, list.ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+}
+
+func TestLists_Move(t *testing.T) {
+	ctx := context.Background()
+	lists := memoryLists(t)
+	home, _ := lists.Get(list.DefaultList)
+	work, _ := lists.Create("work")
+	work.Add(ctx, "Standup")
+
+	home.Add(ctx, "Taxes")
+	home.AddSubtask(ctx, 0, "Receipts")
+	home.Add(ctx, "Call accountant")
+	home.Patch(ctx, 2, list.ItemPatch{AddBlockedBy: []int{0}, AddTags: []string{"money"}})
+
+	moved, err := lists.Move(ctx, 2, list.DefaultList, "work")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	//next ID of the work list, blockers from the old list are dropped, the rest travels along
+	if moved.ID != 1 || moved.BlockedBy != nil || !moved.HasTag("money") {
+		t.Errorf("Unexpected moved item %+v", moved)
+	}
+
+	//a parent moves alone, its subtasks move up in the old list
+	if _, err := lists.Move(ctx, 0, list.DefaultList, "work"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	items, _ := home.GetAll(ctx)
+	if len(items) != 1 || items[0].Description != "Receipts" || items[0].IsSubtask() {
+		t.Errorf("Expected only the subtask left at the top level, got %+v", items)
+	}
+
+	if _, err := lists.Move(ctx, 42, list.DefaultList, "work"); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := lists.Move(ctx, 0, "work", "WORK"); !errors.Is(err, list.ErrInvalidList) {
+		t.Errorf("Expected ErrInvalidList moving an item to its own list, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package list
+
+import (
+	"fmt"
+	"strings"
+)
+
+// P0 is the most urgent, items without a priority sort after P3
+const (
+	PriorityP0 = "P0"
+	PriorityP1 = "P1"
+	PriorityP2 = "P2"
+	PriorityP3 = "P3"
+)
+
+// normalizes p0..p3 to P0..P3, an empty string or "none" clears the priority
+func ParsePriority(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch s {
+	case PriorityP0, PriorityP1, PriorityP2, PriorityP3:
+		return s, nil
+	case "", "NONE":
+		return "", nil
+	}
+	return "", fmt.Errorf("%w: %q, use P0, P1, P2, P3 or none", ErrInvalidPriority, s)
+}
+
+func SetPriority(items []Item, id int, priority string) ([]Item, error) {
+	priority, err := ParsePriority(priority)
+	if err != nil {
+		return items, err
+	}
+	for i, item := range items {
+		if item.ID == id {
+			items[i].Priority = priority
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
@@ -0,0 +1,165 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo-cli/list"
+)
+
+// comparison operators, ":" and "=" both mean equality
+var operators = []string{"<=", ">=", ":", "=", "<", ">"}
+
+// field:value or field<value etc. Dates compare by whole days in the local zone,
+// so due<2026-11-01 means before that day and due:2026-11-01 anywhere within it.
+type Field struct {
+	Name  string
+	Op    string
+	Value string
+
+	id       int
+	from, to time.Time // the day (or instant) a date value covers, to is exclusive
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%s%s%q", f.Name, f.Op, f.Value)
+}
+
+// builds the node for one field term, pos is where the term starts in the query
+func newField(q string, pos int, name, op, value string) (Node, error) {
+	fail := func(offset int, format string, args ...any) (Node, error) {
+		return nil, &ParseError{Query: q, Pos: pos + offset, Msg: fmt.Sprintf(format, args...)}
+	}
+	valuePos := len(name) + len(op)
+	f := Field{Name: strings.ToLower(name), Op: op, Value: value}
+	if f.Op == "=" {
+		f.Op = ":"
+	}
+	if value == "" {
+		return fail(valuePos, "missing value for %s", f.Name)
+	}
+
+	var err error
+	switch f.Name {
+	case "status", "tag", "description", "desc":
+		if f.Op != ":" {
+			return fail(len(name), "%s only supports ':'", f.Name)
+		}
+		f.Value = strings.ToLower(value)
+		if f.Name == "desc" {
+			f.Name = "description"
+		}
+		if f.Name == "status" {
+			f.Value = strings.ReplaceAll(f.Value, "_", " ")
+			if statuses := list.CurrentWorkflow().Statuses; !slices.Contains(statuses, f.Value) {
+				return fail(valuePos, "unknown status %q, use one of %s", value, strings.Join(statuses, ", "))
+			}
+		}
+		if f.Name == "tag" {
+			if f.Value, err = list.NormalizeTag(value); err != nil {
+				return fail(valuePos, "invalid tag %q", value)
+			}
+		}
+	case "priority":
+		if f.Value, err = list.ParsePriority(value); err != nil {
+			return fail(valuePos, "unknown priority %q, use P0 to P3 or none", value)
+		}
+		if f.Value == "" && f.Op != ":" {
+			return fail(valuePos, "none only supports ':'")
+		}
+	case "id":
+		if f.id, err = strconv.Atoi(value); err != nil {
+			return fail(valuePos, "id must be an integer")
+		}
+	case "due", "created", "updated", "started", "completed":
+		if strings.EqualFold(value, "none") {
+			if f.Op != ":" {
+				return fail(valuePos, "none only supports ':'")
+			}
+			f.Value = "none"
+			break
+		}
+		if f.from, f.to, err = parseDay(value); err != nil {
+			return fail(valuePos, "invalid date %q, use YYYY-MM-DD or RFC 3339", value)
+		}
+	default:
+		return fail(0, "unknown field %q", name)
+	}
+	return f, nil
+}
+
+// a date covers that whole local day, an RFC 3339 time just that instant
+func parseDay(value string) (time.Time, time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(value)); err == nil {
+		return t, t.Add(time.Nanosecond), nil
+	}
+	day, err := time.ParseInLocation(list.DateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid date")
+	}
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+func (f Field) Match(item list.Item) bool {
+	switch f.Name {
+	case "status":
+		return item.Status == f.Value
+	case "tag":
+		return item.HasTag(f.Value)
+	case "description":
+		return Text(f.Value).Match(item)
+	case "priority":
+		if f.Value == "" || item.Priority == "" {
+			return f.Value == item.Priority
+		}
+		return compare(strings.Compare(item.Priority, f.Value), f.Op)
+	case "id":
+		return compare(item.ID-f.id, f.Op)
+	case "due":
+		return f.matchTime(item.DueAt)
+	case "created":
+		return f.matchTime(item.CreatedAt)
+	case "updated":
+		return f.matchTime(item.UpdatedAt)
+	case "started":
+		return f.matchTime(item.StartedAt)
+	case "completed":
+		return f.matchTime(item.CompletedAt)
+	}
+	return false
+}
+
+// c is the result of comparing the item's value with the query's
+func compare(c int, op string) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return c == 0
+}
+
+func (f Field) matchTime(t time.Time) bool {
+	if f.Value == "none" || t.IsZero() {
+		return f.Value == "none" && t.IsZero()
+	}
+	switch f.Op {
+	case "<":
+		return t.Before(f.from)
+	case "<=":
+		return t.Before(f.to)
+	case ">":
+		return !t.Before(f.to)
+	case ">=":
+		return !t.Before(f.from)
+	}
+	return !t.Before(f.from) && t.Before(f.to)
+}
@@ -0,0 +1,66 @@
+package query
+
+import "strings"
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokLParen
+	tokRParen
+	// a '-' directly before '(', negating the group
+	tokNot
+	tokEOF
+)
+
+// pos is the byte offset of the token in the query
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// splits a query into words, quoted phrases, parentheses and the '-' of -(...). Quotes inside a word
+// (status:"not started") become part of that word with the quotes removed.
+func lex(q string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(q) {
+		switch c := q[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '-' && i+1 < len(q) && q[i+1] == '(':
+			tokens = append(tokens, token{kind: tokNot, text: "-", pos: i})
+			i++
+		default:
+			start := i
+			kind := tokWord
+			if c == '"' {
+				kind = tokPhrase
+			}
+			var b strings.Builder
+			for i < len(q) && !strings.ContainsRune(" \t\n()", rune(q[i])) {
+				if q[i] != '"' {
+					b.WriteByte(q[i])
+					i++
+					continue
+				}
+				end := strings.IndexByte(q[i+1:], '"')
+				if end < 0 {
+					return nil, &ParseError{Query: q, Pos: i, Msg: "unterminated quote"}
+				}
+				b.WriteString(q[i+1 : i+1+end])
+				i += end + 2
+			}
+			tokens = append(tokens, token{kind: kind, text: b.String(), pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(q)}), nil
+}
@@ -0,0 +1,172 @@
+package query
+
+import "strings"
+
+// parses q into an AST. An empty query matches every item.
+//
+//	query  = or
+//	or     = and { "OR" and }
+//	and    = unary { ["AND"] unary }
+//	unary  = ("NOT" | "-") unary | "(" or ")" | term
+//	term   = field op value | word | "phrase"
+func Parse(q string) (Node, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: q, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return And{}, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorAt(t.pos, "unexpected "+describe(t))
+	}
+	return node, nil
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorAt(pos int, msg string) *ParseError {
+	return &ParseError{Query: p.query, Pos: pos, Msg: msg}
+}
+
+// only upper case keywords are operators, so "or" is still a searchable word
+func isKeyword(t token, kw string) bool {
+	return t.kind == tokWord && t.text == kw
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokRParen:
+		return "')'"
+	}
+	return "'" + t.text + "'"
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := Or{first}
+	for isKeyword(p.peek(), "OR") {
+		p.next()
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return nodes, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes And
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || t.kind == tokRParen || isKeyword(t, "OR") {
+			break
+		}
+		if isKeyword(t, "AND") && len(nodes) > 0 {
+			p.next()
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	switch len(nodes) {
+	case 0:
+		t := p.peek()
+		return nil, p.errorAt(t.pos, "expected a search term, got "+describe(t))
+	case 1:
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.next()
+	switch {
+	case isKeyword(t, "NOT"), t.kind == tokNot:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{node}, nil
+
+	case t.kind == tokWord && len(t.text) > 1 && t.text[0] == '-':
+		node, err := p.term(token{kind: tokWord, text: t.text[1:], pos: t.pos + 1})
+		if err != nil {
+			return nil, err
+		}
+		return Not{node}, nil
+
+	case t.kind == tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorAt(t.pos, "missing closing parenthesis")
+		}
+		p.next()
+		return node, nil
+
+	case t.kind == tokRParen, t.kind == tokEOF, isKeyword(t, "AND"), isKeyword(t, "OR"):
+		return nil, p.errorAt(t.pos, "expected a search term, got "+describe(t))
+	}
+	return p.term(t)
+}
+
+// a word with an operator after a name is a field term, anything else searches the description
+func (p *parser) term(t token) (Node, error) {
+	if t.kind == tokPhrase {
+		return Text(t.text), nil
+	}
+	for i, c := range t.text {
+		if strings.ContainsRune(":<>=", c) {
+			if i == 0 {
+				break
+			}
+			op := t.text[i : i+1]
+			for _, candidate := range operators {
+				if strings.HasPrefix(t.text[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			return newField(p.query, t.pos, t.text[:i], op, t.text[i+len(op):])
+		}
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			break
+		}
+	}
+	return Text(t.text), nil
+}
@@ -0,0 +1,106 @@
+// Package query parses search queries such as
+//
+//	status:started tag:backend due<2026-11-01 "login bug"
+//
+// into an AST that is evaluated against list items. Terms next to each other must all
+// match, OR, NOT (or a leading -) and parentheses combine them. Bare words and quoted
+// phrases search the description.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"todo-cli/list"
+)
+
+// a query that could not be parsed, Pos is the byte offset of the offending input
+type ParseError struct {
+	Query string
+	Pos   int
+	Msg   string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Column())
+}
+
+// 1-based position of the error, for people
+func (e *ParseError) Column() int {
+	return e.Pos + 1
+}
+
+// the query with a caret under the error, for the REPL
+func (e *ParseError) Pointer() string {
+	return e.Query + "\n" + strings.Repeat(" ", e.Pos) + "^"
+}
+
+// a node of the query AST
+type Node interface {
+	Match(item list.Item) bool
+	String() string
+}
+
+// matches when every node does, an empty And matches everything
+type And []Node
+
+// matches when any node does
+type Or []Node
+
+type Not struct {
+	Node Node
+}
+
+// a bare word or quoted phrase, matched case-insensitively against the description
+type Text string
+
+func (n And) Match(item list.Item) bool {
+	for _, node := range n {
+		if !node.Match(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n Or) Match(item list.Item) bool {
+	for _, node := range n {
+		if node.Match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n Not) Match(item list.Item) bool {
+	return !n.Node.Match(item)
+}
+
+func (n Text) Match(item list.Item) bool {
+	return strings.Contains(strings.ToLower(item.Description), strings.ToLower(string(n)))
+}
+
+func (n And) String() string { return join(n, " AND ") }
+func (n Or) String() string  { return join(n, " OR ") }
+func (n Not) String() string { return "NOT " + n.Node.String() }
+func (n Text) String() string {
+	return fmt.Sprintf("%q", string(n))
+}
+
+func join(nodes []Node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// the items matching node, in their original order
+func Filter(items []list.Item, node Node) []list.Item {
+	matched := []list.Item{}
+	for _, item := range items {
+		if node.Match(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
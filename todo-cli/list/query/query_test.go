@@ -0,0 +1,109 @@
+package query_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-cli/list"
+	"todo-cli/list/query"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{`status:started tag:backend due<2026-11-01 "login bug"`,
+			`(status:"started" AND tag:"backend" AND due<"2026-11-01" AND "login bug")`},
+		{`status:"not started" OR priority<=p1`, `(status:"not started" OR priority<="P1")`},
+		{`-tag:urgent NOT (id>3 AND id<6)`, `(NOT tag:"urgent" AND NOT (id>"3" AND id<"6"))`},
+		{`-(a OR b) bug`, `(NOT ("a" OR "b") AND "bug")`},
+		{`login or bug`, `("login" AND "or" AND "bug")`},
+		{`12:30`, `"12:30"`},
+		{``, `()`},
+	}
+	for _, tt := range tests {
+		node, err := query.Parse(tt.q)
+		if err != nil {
+			t.Errorf("Parse(%q): unexpected error %v", tt.q, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("Parse(%q): expected %s, got %s", tt.q, tt.want, got)
+		}
+	}
+}
+
+func TestParse_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		q      string
+		column int
+	}{
+		{`status:started colour:red`, 16},
+		{`status:done`, 8},
+		{`due<tomorrow`, 5},
+		{`tag:backend "login bug`, 13},
+		{`(status:started OR tag:x`, 1},
+		{`tag:x )`, 7},
+		{`tag:x OR`, 9},
+		{`id>`, 4},
+	}
+	for _, tt := range tests {
+		_, err := query.Parse(tt.q)
+		var perr *query.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(%q): expected a ParseError, got %v", tt.q, err)
+			continue
+		}
+		if perr.Column() != tt.column {
+			t.Errorf("Parse(%q): expected the error at column %d, got %d (%v)", tt.q, tt.column, perr.Column(), err)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.Local) }
+	items := []list.Item{
+		{ID: 1, Description: "Fix login bug", Status: list.StatusStarted, Tags: []string{"backend"}, DueAt: day(20), Priority: list.PriorityP0},
+		{ID: 2, Description: "Login page styles", Status: list.StatusStarted, Tags: []string{"frontend"}, DueAt: day(25)},
+		{ID: 3, Description: "Write docs", Status: list.StatusNotStarted, Tags: []string{"backend", "urgent"}, Priority: list.PriorityP2},
+	}
+
+	tests := []struct {
+		q    string
+		want []int
+	}{
+		{`status:started tag:backend due<2026-10-21 "login bug"`, []int{1}},
+		{`login`, []int{1, 2}},
+		{`due:2026-10-25`, []int{2}},
+		{`due>=2026-10-20`, []int{1, 2}},
+		{`due>2026-10-20`, []int{2}},
+		{`due:none`, []int{3}},
+		{`priority<=p2`, []int{1, 3}},
+		{`priority:none`, []int{2}},
+		{`tag:backend -tag:urgent`, []int{1}},
+		{`status:not_started OR tag:frontend`, []int{2, 3}},
+		{`NOT (id<2 OR id>2)`, []int{2}},
+		{``, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		node, err := query.Parse(tt.q)
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error %v", tt.q, err)
+		}
+		var got []int
+		for _, item := range query.Filter(items, node) {
+			got = append(got, item.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.q, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: expected %v, got %v", tt.q, tt.want, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package list
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// how often a recurring item comes back
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// layout of UNTIL in a rule, the date form of RFC 5545
+const untilLayout = "20060102"
+
+// the weekday codes of BYDAY, in time.Weekday order
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// a recurrence rule, the subset of iCalendar RRULE (RFC 5545) items can repeat on:
+//
+//	FREQ=DAILY;INTERVAL=2              every other day
+//	FREQ=WEEKLY;BYDAY=MO,TH            Mondays and Thursdays
+//	FREQ=MONTHLY;BYMONTHDAY=15         the 15th of every month, -1 for the last day
+//	FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION   3 days after the last one was completed
+//
+// UNTIL=YYYYMMDD ends the series after that day.
+type Rule struct {
+	Freq     string
+	Interval int
+	// weekly only, empty means the weekday of the previous due date
+	ByDay []time.Weekday
+	// monthly only, 0 means the day of the previous due date
+	ByMonthDay int
+	// last day an occurrence may be due on, zero for no end
+	Until time.Time
+	// counts the interval from the completion instead of following the calendar
+	FromCompletion bool
+}
+
+func (r Rule) IsZero() bool {
+	return r.Freq == ""
+}
+
+// parses an RRULE (an "RRULE:" prefix is allowed) or one of the shortcuts daily,
+// weekly and monthly. An empty string or "none" returns the zero Rule (not repeating).
+func ParseRule(s string) (Rule, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "RRULE:")
+	switch s {
+	case "", "NONE":
+		return Rule{}, nil
+	case FreqDaily, FreqWeekly, FreqMonthly:
+		return Rule{Freq: s, Interval: 1}, nil
+	}
+
+	invalid := func(format string, args ...any) (Rule, error) {
+		return Rule{}, fmt.Errorf("%w: %q, %s", ErrInvalidRule, s, fmt.Sprintf(format, args...))
+	}
+	r := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for part := range strings.SplitSeq(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return invalid("expected NAME=VALUE, got %q", part)
+		}
+		if seen[name] {
+			return invalid("%s given twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return invalid("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return invalid("INTERVAL must be a positive number")
+			}
+			r.Interval = n
+		case "BYDAY":
+			for code := range strings.SplitSeq(value, ",") {
+				day := slices.Index(weekdayCodes, code)
+				if day < 0 {
+					return invalid("unknown weekday %q, use MO, TU, WE, TH, FR, SA or SU", code)
+				}
+				if !slices.Contains(r.ByDay, time.Weekday(day)) {
+					r.ByDay = append(r.ByDay, time.Weekday(day))
+				}
+			}
+			slices.Sort(r.ByDay)
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n == 0 || n < -1 || n > 31 {
+				return invalid("BYMONTHDAY must be 1 to 31, or -1 for the last day")
+			}
+			r.ByMonthDay = n
+		case "UNTIL":
+			until, err := time.Parse(untilLayout, value)
+			if err != nil {
+				return invalid("UNTIL must be a date like 20261231")
+			}
+			r.Until = until
+		case "X-FROM":
+			if value != "COMPLETION" {
+				return invalid("X-FROM can only be COMPLETION")
+			}
+			r.FromCompletion = true
+		default:
+			return invalid("%s is not supported, use FREQ, INTERVAL, BYDAY, BYMONTHDAY, UNTIL or X-FROM", name)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return invalid("FREQ is required")
+	case len(r.ByDay) > 0 && r.Freq != FreqWeekly:
+		return invalid("BYDAY needs FREQ=WEEKLY")
+	case r.ByMonthDay != 0 && r.Freq != FreqMonthly:
+		return invalid("BYMONTHDAY needs FREQ=MONTHLY")
+	case r.FromCompletion && (len(r.ByDay) > 0 || r.ByMonthDay != 0):
+		return invalid("X-FROM=COMPLETION can't be combined with BYDAY or BYMONTHDAY")
+	}
+	return r, nil
+}
+
+// the normalized RRULE, what items store
+func (r Rule) String() string {
+	if r.IsZero() {
+		return ""
+	}
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdayCodes[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format(untilLayout))
+	}
+	if r.FromCompletion {
+		parts = append(parts, "X-FROM=COMPLETION")
+	}
+	return strings.Join(parts, ";")
+}
+
+// the first occurrence on a later day than t, at the same clock time in t's location.
+// Zero when the rule never matches again (e.g. BYMONTHDAY=31 every 12 months from February).
+func (r Rule) Next(t time.Time) time.Time {
+	interval := max(r.Interval, 1)
+	day := func(days int) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		return day(interval)
+
+	case FreqWeekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{t.Weekday()}
+		}
+		//weeks start on Monday (WKST=MO), only every interval-th week counts
+		monday := int(t.Weekday()+6) % 7
+		for d := 1; d <= 7*interval+7; d++ {
+			next := day(d)
+			week := (monday + d) / 7
+			if week%interval == 0 && slices.Contains(byDay, next.Weekday()) {
+				return next
+			}
+		}
+
+	case FreqMonthly:
+		for m := 0; m <= 12*interval*4; m += interval {
+			first := time.Date(t.Year(), t.Month()+time.Month(m), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+			last := first.AddDate(0, 1, -1).Day()
+			dom := r.ByMonthDay
+			switch {
+			case dom == 0:
+				dom = t.Day()
+			case dom < 0:
+				dom = last
+			}
+			//months too short for the day are skipped, as in RFC 5545
+			if dom > last {
+				continue
+			}
+			if next := first.AddDate(0, 0, dom-1); next.After(t) && !sameDay(next, t) {
+				return next
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// the due date of the occurrence after one completed at, zero when the series has ended.
+// Calendar rules skip the occurrences missed while the item was late, so the next one is
+// never due on or before the completion day.
+func (r Rule) nextDue(due, completed time.Time) time.Time {
+	loc := time.Local
+	if !due.IsZero() {
+		loc = due.Location()
+	}
+	//without a due date occurrences are due at the end of the day, like plain dates
+	y, m, d := completed.In(loc).Date()
+	done := time.Date(y, m, d, 23, 59, 59, 0, loc)
+	if !due.IsZero() {
+		done = time.Date(y, m, d, due.Hour(), due.Minute(), due.Second(), 0, loc)
+	}
+
+	var next time.Time
+	switch {
+	case r.FromCompletion && r.Freq == FreqMonthly:
+		next = addMonths(done, r.Interval)
+	case r.FromCompletion && r.Freq == FreqWeekly:
+		next = done.AddDate(0, 0, 7*r.Interval)
+	case r.FromCompletion:
+		next = done.AddDate(0, 0, r.Interval)
+	default:
+		next = due
+		if next.IsZero() {
+			next = done
+		}
+		next = r.Next(next)
+		for !next.IsZero() && !next.After(done) {
+			next = r.Next(next)
+		}
+	}
+
+	if next.IsZero() {
+		return next
+	}
+	if !r.Until.IsZero() {
+		uy, um, ud := r.Until.Date()
+		if next.After(time.Date(uy, um, ud, 23, 59, 59, 0, loc)) {
+			return time.Time{}
+		}
+	}
+	return next
+}
+
+// adds months, ending up on the last day of the month when the day doesn't exist there
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+// sets (or with "" or "none" clears) the recurrence rule of the item with id
+func SetRepeat(items []Item, id int, rule string) ([]Item, error) {
+	r, err := ParseRule(rule)
+	if err != nil {
+		return items, err
+	}
+	for i, item := range items {
+		if item.ID == id {
+			items[i].Repeat = r.String()
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// appends the next occurrence of the recurring item at index i, which was just finished.
+// The rule moves over to the new item, so completing this one again doesn't repeat it twice.
+func spawnNext(items []Item, i int) []Item {
+	done := items[i]
+	r, err := ParseRule(done.Repeat)
+	if err != nil || r.IsZero() {
+		//only a hand edited file gets here
+		slog.Warn("Ignoring invalid recurrence rule", "id", done.ID, "repeat", done.Repeat, "error", err)
+		return items
+	}
+	items[i].Repeat = ""
+
+	due := r.nextDue(done.DueAt, done.CompletedAt)
+	if due.IsZero() {
+		slog.Info("Recurring item ended", "id", done.ID, "repeat", done.Repeat)
+		return items
+	}
+	created := timestamp()
+	next := Item{
+		ID:          GetNextID(items),
+		Description: done.Description,
+		Status:      workflow.Initial(),
+		CreatedAt:   created,
+		UpdatedAt:   created,
+		DueAt:       due,
+		Priority:    done.Priority,
+		Tags:        done.Tags,
+		Notes:       done.Notes,
+		ParentID:    done.ParentID,
+		Repeat:      done.Repeat,
+	}
+	slog.Info("Recurring item repeated", "id", done.ID, "next_id", next.ID, "due", due)
+	return append(items, next)
+}
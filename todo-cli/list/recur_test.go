@@ -0,0 +1,156 @@
+package list_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-cli/list"
+)
+
+func TestParseRule(t *testing.T) {
+	valid := []struct {
+		in, want string
+	}{
+		{"weekly", "FREQ=WEEKLY"},
+		{"none", ""},
+		{"rrule:freq=weekly;byday=th,mo", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20261231", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20261231"},
+		{"X-FROM=COMPLETION;INTERVAL=3;FREQ=DAILY", "FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION"},
+	}
+	for _, tc := range valid {
+		r, err := list.ParseRule(tc.in)
+		if err != nil || r.String() != tc.want {
+			t.Errorf("ParseRule(%q) = %q, %v, want %q", tc.in, r.String(), err, tc.want)
+		}
+	}
+
+	for _, in := range []string{
+		"FREQ=YEARLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;COUNT=3",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYDAY=MO;X-FROM=COMPLETION",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"every day",
+	} {
+		if _, err := list.ParseRule(in); !errors.Is(err, list.ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) expected ErrInvalidRule, got %v", in, err)
+		}
+	}
+}
+
+func TestRule_Next(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 9, 30, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		rule       string
+		from, want time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=2", day(10, 5), day(10, 7)},
+		//Monday the 5th
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(10, 5), day(10, 8)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(10, 8), day(10, 12)},
+		{"FREQ=WEEKLY", day(10, 8), day(10, 15)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", day(10, 5), day(10, 9)},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", day(10, 9), day(10, 19)},
+		{"FREQ=MONTHLY", day(10, 15), day(11, 15)},
+		{"FREQ=MONTHLY;BYMONTHDAY=20", day(10, 15), day(10, 20)},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", day(1, 31), day(2, 28)},
+		//February has no 31st
+		{"FREQ=MONTHLY;BYMONTHDAY=31", day(1, 31), day(3, 31)},
+	}
+	for _, tc := range cases {
+		r, err := list.ParseRule(tc.rule)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if got := r.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%s after %s = %s, want %s", tc.rule, tc.from.Format(time.DateOnly), got, tc.want)
+		}
+	}
+}
+
+// completes a recurring item due on Monday 2026-10-05 at the given time
+func completeRecurring(t *testing.T, rule string, at time.Time) []list.Item {
+	t.Helper()
+	restore := list.SetClock(func() time.Time { return at })
+	defer restore()
+
+	items := list.Add(nil, "Ops checklist")
+	items, _ = list.SetTags(items, 0, []string{"ops"})
+	items, _ = list.SetDue(items, 0, time.Date(2026, 10, 5, 18, 0, 0, 0, time.UTC))
+	items, err := list.SetRepeat(items, 0, rule)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if items, err = list.UpdateStatus(items, 0, list.StatusCompleted); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return items
+}
+
+func TestUpdateStatus_Repeat(t *testing.T) {
+	//completed on Monday, next one is on Thursday
+	items := completeRecurring(t, "FREQ=WEEKLY;BYDAY=MO,TH", time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC))
+	if len(items) != 2 {
+		t.Fatalf("Expected the next occurrence to be added, got %+v", items)
+	}
+	next := items[1]
+	if want := time.Date(2026, 10, 8, 18, 0, 0, 0, time.UTC); !next.DueAt.Equal(want) {
+		t.Errorf("Expected the next one due %s, got %s", want, next.DueAt)
+	}
+	if next.ID != 1 || next.Status != list.StatusNotStarted || next.Description != "Ops checklist" || !next.HasTag("ops") {
+		t.Errorf("Unexpected next occurrence %+v", next)
+	}
+	if next.Repeat != "FREQ=WEEKLY;BYDAY=MO,TH" || items[0].Repeat != "" {
+		t.Errorf("Expected the rule to move to the next occurrence, got %q and %q", items[0].Repeat, next.Repeat)
+	}
+
+	//reopening and completing the old one again doesn't repeat it twice
+	items, _ = list.UpdateStatus(items, 0, list.StatusNotStarted)
+	items, _ = list.UpdateStatus(items, 0, list.StatusCompleted)
+	if len(items) != 2 {
+		t.Errorf("Expected no second repeat, got %d items", len(items))
+	}
+}
+
+func TestUpdateStatus_RepeatSkipsMissed(t *testing.T) {
+	//daily, completed four days late: the next one is due tomorrow, not in the past
+	items := completeRecurring(t, "daily", time.Date(2026, 10, 9, 20, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 10, 10, 18, 0, 0, 0, time.UTC); len(items) != 2 || !items[1].DueAt.Equal(want) {
+		t.Errorf("Expected the next one due %s, got %+v", want, items)
+	}
+
+	//counted from the completion, keeping the time of day of the due date
+	items = completeRecurring(t, "FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION", time.Date(2026, 10, 9, 20, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 10, 12, 18, 0, 0, 0, time.UTC); len(items) != 2 || !items[1].DueAt.Equal(want) {
+		t.Errorf("Expected the next one due %s, got %+v", want, items)
+	}
+
+	//the next Monday is after UNTIL, the series ends
+	items = completeRecurring(t, "FREQ=WEEKLY;UNTIL=20261010", time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC))
+	if len(items) != 1 {
+		t.Errorf("Expected no next occurrence after UNTIL, got %+v", items)
+	}
+}
+
+func TestPatch_RepeatAndComplete(t *testing.T) {
+	restore := list.SetClock(func() time.Time { return time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC) })
+	defer restore()
+
+	items := list.Add(nil, "Backup")
+	rule, done := "FREQ=DAILY;X-FROM=COMPLETION", list.StatusCompleted
+	updated, err := list.Patch(items, 0, list.ItemPatch{Repeat: &rule, Status: &done})
+	if err != nil || len(updated) != 2 || updated[1].DueAt.IsZero() {
+		t.Fatalf("Expected one patch to set the rule and repeat the item, got %+v (%v)", updated, err)
+	}
+
+	bad := "FREQ=HOURLY"
+	if _, err := list.Patch(items, 0, list.ItemPatch{Repeat: &bad}); !errors.Is(err, list.ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package list
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// descriptions count more than notes when ranking
+const (
+	descriptionWeight = 2
+	notesWeight       = 1
+)
+
+// default and maximum number of search results
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 500
+)
+
+// splits text into lower case words of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// inverted index over descriptions and notes, owned by the actor goroutine and
+// updated with every mutation so searches never scan the whole list
+type searchIndex struct {
+	postings map[string]map[int]int // term -> item ID -> weighted term frequency
+	docs     map[int]indexedItem
+	terms    []string // every indexed term, sorted for prefix lookups
+}
+
+// the latest copy of an item, so results need no scan of the list, and its
+// distinct terms for removal
+type indexedItem struct {
+	item  Item
+	terms []string
+}
+
+func newSearchIndex(items []Item) *searchIndex {
+	idx := &searchIndex{postings: map[string]map[int]int{}, docs: map[int]indexedItem{}}
+	for _, item := range items {
+		idx.terms = append(idx.terms, idx.index(item)...)
+	}
+	sort.Strings(idx.terms)
+	return idx
+}
+
+func (idx *searchIndex) add(item Item) {
+	for _, term := range idx.index(item) {
+		i := sort.SearchStrings(idx.terms, term)
+		idx.terms = append(idx.terms, "")
+		copy(idx.terms[i+1:], idx.terms[i:])
+		idx.terms[i] = term
+	}
+}
+
+// adds the postings of item and returns the terms that were not indexed before
+func (idx *searchIndex) index(item Item) []string {
+	freq := map[string]int{}
+	for _, term := range tokenize(item.Description) {
+		freq[term] += descriptionWeight
+	}
+	for _, term := range tokenize(item.Notes) {
+		freq[term] += notesWeight
+	}
+
+	var added []string
+	terms := make([]string, 0, len(freq))
+	for term, n := range freq {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = map[int]int{}
+			idx.postings[term] = docs
+			added = append(added, term)
+		}
+		docs[item.ID] = n
+		terms = append(terms, term)
+	}
+	idx.docs[item.ID] = indexedItem{item: item, terms: terms}
+	return added
+}
+
+func (idx *searchIndex) remove(id int) {
+	for _, term := range idx.docs[id].terms {
+		docs := idx.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+			i := sort.SearchStrings(idx.terms, term)
+			idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// keeps the index in line with one change to the list
+func (idx *searchIndex) apply(ev Event) {
+	if ev.Before != nil {
+		if ev.After != nil && ev.Before.Description == ev.After.Description && ev.Before.Notes == ev.After.Notes {
+			doc := idx.docs[ev.ID]
+			doc.item = *ev.After
+			idx.docs[ev.ID] = doc
+			return
+		}
+		idx.remove(ev.Before.ID)
+	}
+	if ev.After != nil {
+		idx.add(*ev.After)
+	}
+}
+
+// the items containing every word of q, each word also matching as a prefix
+// ("log" finds "login"). Ranked by weighted term frequency times inverse document
+// frequency, exact word matches scoring above prefix matches, ties by ID.
+func (idx *searchIndex) search(q string, limit int) []Item {
+	words := tokenize(q)
+	if len(words) == 0 {
+		return []Item{}
+	}
+
+	//the indexed terms each word matches, rarest word first so the candidate set starts small
+	type match struct {
+		terms []string
+		df    int
+	}
+	matches := make([]match, len(words))
+	for w, word := range words {
+		for i := sort.SearchStrings(idx.terms, word); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], word); i++ {
+			matches[w].terms = append(matches[w].terms, idx.terms[i])
+			matches[w].df += len(idx.postings[idx.terms[i]])
+		}
+		if matches[w].df == 0 {
+			return []Item{}
+		}
+	}
+	sort.Slice(matches, func(a, b int) bool { return matches[a].df < matches[b].df })
+
+	n := float64(len(idx.docs))
+	weight := func(term string, exact bool) float64 {
+		idf := math.Log(1 + n/float64(len(idx.postings[term])))
+		if !exact {
+			idf /= 2
+		}
+		return idf
+	}
+
+	scores := map[int]float64{}
+	for _, term := range matches[0].terms {
+		w := weight(term, slices.Contains(words, term))
+		for id, freq := range idx.postings[term] {
+			scores[id] += float64(freq) * w
+		}
+	}
+	for _, m := range matches[1:] {
+		for id, score := range scores {
+			extra := 0.0
+			for _, term := range m.terms {
+				if freq, ok := idx.postings[term][id]; ok {
+					extra += float64(freq) * weight(term, slices.Contains(words, term))
+				}
+			}
+			if extra == 0 {
+				delete(scores, id)
+				continue
+			}
+			scores[id] = score + extra
+		}
+	}
+
+	ids := make([]int, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if scores[ids[a]] != scores[ids[b]] {
+			return scores[ids[a]] > scores[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	results := make([]Item, len(ids))
+	for i, id := range ids {
+		results[i] = idx.docs[id].item
+	}
+	return results
+}
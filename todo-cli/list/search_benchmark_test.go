@@ -0,0 +1,96 @@
+package list
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var benchWords = []string{"login", "bug", "docs", "backend", "frontend", "deploy", "review", "cache", "refactor", "metrics"}
+
+func benchItems(n int) []Item {
+	items := make([]Item, n)
+	for i := range items {
+		items[i] = Item{
+			ID:          i,
+			Description: fmt.Sprintf("%s %s task %d", benchWords[i%len(benchWords)], benchWords[(i/7)%len(benchWords)], i),
+			Status:      StatusNotStarted,
+		}
+	}
+	return items
+}
+
+// a selective query and one matching a large share of the list
+var benchQueries = map[string]string{"selective": "review 4242", "broad": "deploy cach"}
+
+// what every search cost without the index: tokenizing the whole list
+func BenchmarkLinearScanSearch(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		items := benchItems(n)
+		for name, q := range benchQueries {
+			words := tokenize(q)
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					matched := []Item{}
+					for _, item := range items {
+						terms := tokenize(item.Description)
+						missing := slices.ContainsFunc(words, func(word string) bool {
+							return !slices.ContainsFunc(terms, func(term string) bool { return strings.HasPrefix(term, word) })
+						})
+						if !missing {
+							matched = append(matched, item)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSearchIndex(b *testing.B) {
+	for _, n := range []int{1000, 10000, 50000} {
+		idx := newSearchIndex(benchItems(n))
+		for name, q := range benchQueries {
+			b.Run(fmt.Sprintf("%s/%d", name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					idx.search(q, DefaultSearchLimit)
+				}
+			})
+		}
+	}
+}
+
+// the same through the actor, including the round trip to its goroutine
+func BenchmarkActorSearch(b *testing.B) {
+	ctx := context.Background()
+	actor := NewListActor(benchItems(50000))
+	defer actor.Stop()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := actor.Search(ctx, benchQueries["selective"], DefaultSearchLimit); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// cost of keeping the index up to date on every mutation
+func BenchmarkActorUpdateDescriptionIndexed(b *testing.B) {
+	ctx := context.Background()
+	actor := NewListActor(benchItems(10000))
+	defer actor.Stop()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := actor.UpdateDescription(ctx, i%10000, fmt.Sprintf("renamed %s item %d", benchWords[i%len(benchWords)], i)); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkBuildSearchIndex(b *testing.B) {
+	items := benchItems(10000)
+	for i := 0; i < b.N; i++ {
+		newSearchIndex(items)
+	}
+}
@@ -0,0 +1,95 @@
+package list
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ids(items []Item) []int {
+	out := []int{}
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"fix", "login", "bug", "on", "ios", "17", "café"}, tokenize("Fix LOGIN-bug on iOS 17, Café!"))
+}
+
+func TestSearchIndex(t *testing.T) {
+	idx := newSearchIndex([]Item{
+		{ID: 1, Description: "Fix login bug"},
+		{ID: 2, Description: "Write docs", Notes: "mention the login flow"},
+		{ID: 3, Description: "Logging cleanup"},
+		{ID: 4, Description: "Login login page redesign"},
+		{ID: 5, Description: "Docstring updates"},
+	})
+
+	//description hits outrank notes, repeated words outrank single ones
+	assert.Equal(t, []int{4, 1, 2}, ids(idx.search("LOGIN", 0)))
+	//prefixes match, rarer words weigh more
+	assert.Equal(t, []int{4, 3, 1, 2}, ids(idx.search("log", 0)))
+	//exact words outrank prefix matches
+	assert.Equal(t, []int{2, 5}, ids(idx.search("docs", 0)))
+	//every word has to match
+	assert.Equal(t, []int{1}, ids(idx.search("login bug", 0)))
+	assert.Equal(t, []int{4}, ids(idx.search("log", 1)))
+	assert.Empty(t, idx.search("missing", 0))
+	assert.Empty(t, idx.search("  ", 0))
+}
+
+func TestListActor_SearchFollowsMutations(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{MemoryStore: NewMemoryStore(nil)}
+	actor, err := NewPersistentListActor(store, 0)
+	require.NoError(t, err)
+	defer actor.Stop()
+
+	_, err = actor.Add(ctx, "Fix login bug")
+	require.NoError(t, err)
+	_, err = actor.Add(ctx, "Write docs")
+	require.NoError(t, err)
+
+	found, err := actor.Search(ctx, "login", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, ids(found))
+
+	notes := "covers the login screen"
+	_, err = actor.Patch(ctx, 1, ItemPatch{Notes: &notes})
+	require.NoError(t, err)
+	_, err = actor.UpdateDescription(ctx, 0, "Fix signup bug")
+	require.NoError(t, err)
+	found, err = actor.Search(ctx, "login", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids(found))
+
+	//results carry the current state of the item, not the one it was indexed with
+	_, err = actor.UpdateStatus(ctx, 1, StatusStarted)
+	require.NoError(t, err)
+	found, err = actor.Search(ctx, "login", 0)
+	require.NoError(t, err)
+	assert.Equal(t, StatusStarted, found[0].Status)
+
+	_, err = actor.Delete(ctx, 1)
+	require.NoError(t, err)
+	found, err = actor.Search(ctx, "login", 0)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	//a change that was rolled back must not stay searchable
+	store.mu.Lock()
+	store.fail = true
+	store.mu.Unlock()
+	_, err = actor.Add(ctx, "Unsaved login task")
+	require.Error(t, err)
+	found, err = actor.Search(ctx, "unsaved", 0)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	found, err = actor.Search(ctx, "signup", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, ids(found))
+}
@@ -0,0 +1,95 @@
+package list
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fields that can be sorted on
+const (
+	SortPriority = "priority"
+	SortStatus   = "status"
+	SortDue      = "due"
+	SortCreated  = "created"
+	SortID       = "id"
+)
+
+// one key of a multi-key sort
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+func (k SortKey) String() string {
+	if k.Desc {
+		return "-" + k.Field
+	}
+	return k.Field
+}
+
+// parses a comma separated spec like "priority,-created", a leading - sorts descending
+func ParseSort(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		switch key.Field {
+		case SortPriority, SortStatus, SortDue, SortCreated, SortID:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("%w: %q, use priority, status, due, created or id", ErrInvalidSort, key.Field)
+		}
+	}
+	return keys, nil
+}
+
+// orders items by keys, the first key deciding first. Items missing a value
+// (no priority, no due date) go last in either direction, ties keep their order.
+func Sort(items []Item, keys []SortKey) {
+	sort.SliceStable(items, func(a, b int) bool {
+		for _, key := range keys {
+			c, missing := compareField(items[a], items[b], key.Field)
+			if c == 0 {
+				continue
+			}
+			if key.Desc && !missing {
+				c = -c
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compares one field of a and b. missing reports that exactly one of them has no
+// value, the result then already puts that one last and must not be reversed.
+func compareField(a, b Item, field string) (c int, missing bool) {
+	switch field {
+	case SortPriority:
+		return compareOptional(a.Priority == "", b.Priority == "", strings.Compare(a.Priority, b.Priority))
+	case SortStatus:
+		return workflow.rank(a.Status) - workflow.rank(b.Status), false
+	case SortDue:
+		return compareOptional(a.DueAt.IsZero(), b.DueAt.IsZero(), a.DueAt.Compare(b.DueAt))
+	case SortCreated:
+		return a.CreatedAt.Compare(b.CreatedAt), false
+	default:
+		return a.ID - b.ID, false
+	}
+}
+
+func compareOptional(aMissing, bMissing bool, c int) (int, bool) {
+	switch {
+	case aMissing && bMissing:
+		return 0, false
+	case aMissing:
+		return 1, true
+	case bMissing:
+		return -1, true
+	}
+	return c, false
+}
@@ -0,0 +1,74 @@
+package list_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"todo-cli/list"
+)
+
+func sortIDs(items []list.Item, spec string, t *testing.T) []int {
+	keys, err := list.ParseSort(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	list.Sort(items, keys)
+	var ids []int
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestSort(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	items := func() []list.Item {
+		return []list.Item{
+			{ID: 1, Status: list.StatusCompleted, Priority: list.PriorityP1, CreatedAt: day},
+			{ID: 2, Status: list.StatusNotStarted, CreatedAt: day.AddDate(0, 0, 1), DueAt: day.AddDate(0, 0, 5)},
+			{ID: 3, Status: list.StatusStarted, Priority: list.PriorityP0, CreatedAt: day.AddDate(0, 0, 2)},
+			{ID: 4, Status: list.StatusNotStarted, Priority: list.PriorityP1, CreatedAt: day.AddDate(0, 0, 3), DueAt: day},
+		}
+	}
+
+	tests := []struct {
+		spec string
+		want []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{"-id", []int{4, 3, 2, 1}},
+		{"priority,-created", []int{3, 4, 1, 2}},
+		//items without a priority stay last when descending too
+		{"-priority", []int{1, 4, 3, 2}},
+		{"status,id", []int{2, 4, 3, 1}},
+		{"due", []int{4, 2, 1, 3}},
+		{"-due", []int{2, 4, 1, 3}},
+		{" Created , -ID ", []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		if got := sortIDs(items(), tt.spec, t); !equalIDs(got, tt.want) {
+			t.Errorf("sort %q: expected %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+
+	if _, err := list.ParseSort("priority,size"); !errors.Is(err, list.ErrInvalidSort) {
+		t.Errorf("Expected ErrInvalidSort, got %v", err)
+	}
+}
+
+func TestSetPriority(t *testing.T) {
+	items, err := list.SetPriority(sampleItems(), 1, "p2")
+	if err != nil || items[0].Priority != list.PriorityP2 {
+		t.Errorf("Expected P2, got %q (%v)", items[0].Priority, err)
+	}
+	items, _ = list.SetPriority(items, 1, "none")
+	if items[0].Priority != "" {
+		t.Errorf("Expected the priority to be cleared, got %q", items[0].Priority)
+	}
+	if _, err := list.SetPriority(items, 1, "P7"); !errors.Is(err, list.ErrInvalidPriority) {
+		t.Errorf("Expected ErrInvalidPriority, got %v", err)
+	}
+	if _, err := list.SetPriority(items, 99, "P1"); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package list
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Store persists a to-do list. Callers use it instead of reaching for a data file
+// directly, so the persistence backend can be swapped without touching them.
+type Store interface {
+	// the stored list. Items recovered from a backup come with a *RecoveredError.
+	Load() ([]Item, error)
+	Save(items []Item) error
+
+	Add(description string) ([]Item, error)
+	UpdateDescription(id int, desc string) ([]Item, error)
+	UpdateStatus(id int, status string) ([]Item, error)
+	Delete(id int) ([]Item, error)
+
+	// persists changes the caller already made to its copy of the list, in one write where
+	// the backend allows it. merged is nil unless the stored list held changes the caller
+	// hadn't seen, then it is the stored list with the changes redone on it and redone[i]
+	// tells how change i fared. err is a failed read or write, nothing was saved.
+	Apply(changes []Change) (merged []Item, redone []Redone, err error)
+}
+
+// how a change fared when a store redid it on a list that moved on
+type Redone struct {
+	// the item the change is about in the merged list, a redone add may get another ID
+	ID  int
+	Err error
+}
+
+// one mutation of the list, as made by the actor to its own copy. Put and Remove are
+// the outcome, Redo makes the same mutation again on a list that moved on since.
+type Change struct {
+	// what the mutation was, kept by the journal: add, update_status, delete, ...
+	Op    string
+	ID    int
+	Value string
+	// the items the mutation added or modified and the IDs of those it removed
+	Put    []Item
+	Remove []int
+	// returns the ID of the item the change is about besides the list
+	Redo func([]Item) ([]Item, int, error)
+}
+
+// applies the outcome of the change to items
+func (c Change) applyTo(items []Item) []Item {
+	if len(c.Remove) > 0 {
+		items = slices.DeleteFunc(items, func(item Item) bool { return slices.Contains(c.Remove, item.ID) })
+	}
+	for _, item := range c.Put {
+		items = putItem(items, item)
+	}
+	return items
+}
+
+// keeps the whole list in memory, useful for tests and throwaway sessions
+type MemoryStore struct {
+	mu    sync.Mutex
+	items []Item
+}
+
+func NewMemoryStore(initial []Item) *MemoryStore {
+	return &MemoryStore{items: append([]Item{}, initial...)}
+}
+
+func (s *MemoryStore) Load() ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Item{}, s.items...), nil
+}
+
+func (s *MemoryStore) Save(items []Item) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append([]Item{}, items...)
+	return nil
+}
+
+// nobody else writes to the list, so the changes always apply as they are
+func (s *MemoryStore) Apply(changes []Change) ([]Item, []Redone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := append([]Item{}, s.items...)
+	for _, c := range changes {
+		items = c.applyTo(items)
+	}
+	s.items = items
+	return nil, nil, nil
+}
+
+// applies fn to a copy of the list and keeps the result only when fn succeeds
+func (s *MemoryStore) update(fn func([]Item) ([]Item, error)) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated, err := fn(append([]Item{}, s.items...))
+	if err != nil {
+		return append([]Item{}, s.items...), err
+	}
+	s.items = updated
+	return append([]Item{}, s.items...), nil
+}
+
+func (s *MemoryStore) Add(description string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return Add(items, description), nil
+	})
+}
+
+func (s *MemoryStore) UpdateDescription(id int, desc string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return UpdateDescription(items, id, desc)
+	})
+}
+
+func (s *MemoryStore) UpdateStatus(id int, status string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return UpdateStatus(items, id, status)
+	})
+}
+
+func (s *MemoryStore) Delete(id int) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return Delete(items, id), nil
+	})
+}
+
+// stores the list as a JSON file on disk, every operation is a load-modify-save cycle.
+// Cycles are guarded by an advisory file lock so separate processes sharing the file
+// (REPL, server, load tester) don't lose each other's updates.
+type FileStore struct {
+	filename    string
+	lockTimeout time.Duration
+
+	mu sync.Mutex
+	// fingerprint of the list as this store last read or wrote it, to notice other writers
+	seen [sha256.Size]byte
+}
+
+// lockTimeout <= 0 uses DefaultLockTimeout
+func NewFileStore(filename string, lockTimeout time.Duration) *FileStore {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+	return &FileStore{filename: filename, lockTimeout: lockTimeout}
+}
+
+// runs fn while holding the data file lock
+func (s *FileStore) withLock(exclusive bool, fn func() error) error {
+	return WithFileLock(s.filename, exclusive, s.lockTimeout, fn)
+}
+
+// a missing data file is an empty list, anything else unreadable is an error. Items
+// recovered from the backup come with their *RecoveredError.
+func (s *FileStore) load() ([]Item, error) {
+	items, err := LoadFromFile(s.filename)
+	if errors.Is(err, ErrNotExist) {
+		return []Item{}, nil
+	}
+	return items, err
+}
+
+func (s *FileStore) Load() ([]Item, error) {
+	var items []Item
+	err := s.withLock(false, func() error {
+		var err error
+		items, err = s.load()
+		if err == nil || IsRecovered(err) {
+			s.remember(items)
+		}
+		return err
+	})
+	return items, err
+}
+
+func (s *FileStore) Save(items []Item) error {
+	return s.withLock(true, func() error {
+		if err := SaveToFile(s.filename, items); err != nil {
+			return err
+		}
+		s.remember(items)
+		return nil
+	})
+}
+
+// merges changes into the data file under the lock. While the file still holds what
+// this store last read or wrote the outcome of each change is written as it is. Once
+// another process wrote to it the changes are redone on its content instead, so
+// neither side loses its updates.
+func (s *FileStore) Apply(changes []Change) ([]Item, []Redone, error) {
+	var merged []Item
+	var redone []Redone
+	err := s.withLock(true, func() error {
+		items, err := s.load()
+		if err != nil && !IsRecovered(err) {
+			return err
+		}
+		moved := !s.saw(items)
+		if moved {
+			slog.Info("Data file changed by another process, merging", "file", s.filename, "changes", len(changes))
+			redone = make([]Redone, len(changes))
+		}
+		for i, c := range changes {
+			if !moved || c.Redo == nil {
+				items = c.applyTo(items)
+				if moved {
+					redone[i] = Redone{ID: c.ID}
+				}
+				continue
+			}
+			updated, id, err := c.Redo(items)
+			redone[i] = Redone{ID: id, Err: err}
+			if err != nil {
+				slog.Warn("Change no longer applies to the data file", "op", c.Op, "id", c.ID, "error", err)
+				continue
+			}
+			items = updated
+		}
+		if err := SaveToFile(s.filename, items); err != nil {
+			return err
+		}
+		s.remember(items)
+		if moved {
+			merged = items
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, redone, nil
+}
+
+func fingerprint(items []Item) [sha256.Size]byte {
+	data, _ := json.Marshal(items)
+	return sha256.Sum256(data)
+}
+
+func (s *FileStore) remember(items []Item) {
+	sum := fingerprint(items)
+	s.mu.Lock()
+	s.seen = sum
+	s.mu.Unlock()
+}
+
+// reports whether items is the list this store last read or wrote
+func (s *FileStore) saw(items []Item) bool {
+	sum := fingerprint(items)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sum == s.seen
+}
+
+func (s *FileStore) update(fn func([]Item) ([]Item, error)) ([]Item, error) {
+	var updated []Item
+	err := s.withLock(true, func() error {
+		//a recovered list is saved back as the new data file
+		items, err := s.load()
+		if err != nil && !IsRecovered(err) {
+			return err
+		}
+		updated, err = fn(items)
+		if err != nil {
+			return err
+		}
+		if err := SaveToFile(s.filename, updated); err != nil {
+			slog.Error("Error saving items", "file", s.filename, "error", err)
+			return err
+		}
+		s.remember(updated)
+		return nil
+	})
+	return updated, err
+}
+
+func (s *FileStore) Add(description string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return Add(items, description), nil
+	})
+}
+
+func (s *FileStore) UpdateDescription(id int, desc string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return UpdateDescription(items, id, desc)
+	})
+}
+
+func (s *FileStore) UpdateStatus(id int, status string) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return UpdateStatus(items, id, status)
+	})
+}
+
+func (s *FileStore) Delete(id int) ([]Item, error) {
+	return s.update(func(items []Item) ([]Item, error) {
+		return Delete(items, id), nil
+	})
+}
@@ -0,0 +1,85 @@
+package list_test
+
+import (
+	"path/filepath"
+	"testing"
+	"todo-cli/list"
+)
+
+// runs the same checks against every Store implementation
+func storesUnderTest(t *testing.T) map[string]list.Store {
+	return map[string]list.Store{
+		"memory": list.NewMemoryStore(sampleItems()),
+		"file": func() list.Store {
+			s := list.NewFileStore(filepath.Join(t.TempDir(), "items.json"), 0)
+			if err := s.Save(sampleItems()); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			return s
+		}(),
+		"journal": func() list.Store {
+			s, err := list.OpenJournalStore(filepath.Join(t.TempDir(), "items.json"), 3)
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			if err := s.Save(sampleItems()); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			return s
+		}(),
+	}
+}
+
+func TestStoreOperations(t *testing.T) {
+	for name, store := range storesUnderTest(t) {
+		t.Run(name, func(t *testing.T) {
+			items, err := store.Add("Task 3")
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if len(items) != 3 {
+				t.Errorf("Expected 3 items, got %d", len(items))
+			}
+
+			if _, err := store.UpdateDescription(1, "Updated Task 1"); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if _, err := store.UpdateStatus(2, list.StatusCompleted); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			if _, err := store.UpdateStatus(999, list.StatusCompleted); err == nil {
+				t.Errorf("Expected error for invalid ID, got nil")
+			}
+			if _, err := store.Delete(3); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+
+			loaded, err := store.Load()
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if len(loaded) != 2 {
+				t.Fatalf("Expected 2 items, got %d", len(loaded))
+			}
+			if loaded[0].Description != "Updated Task 1" {
+				t.Errorf("Expected 'Updated Task 1', got %q", loaded[0].Description)
+			}
+			if loaded[1].Status != list.StatusCompleted {
+				t.Errorf("Expected 'completed', got %q", loaded[1].Status)
+			}
+		})
+	}
+}
+
+// changing a loaded slice must not leak back into the store
+func TestMemoryStore_LoadReturnsCopy(t *testing.T) {
+	store := list.NewMemoryStore(sampleItems())
+	items, _ := store.Load()
+	items[0].Description = "changed"
+
+	loaded, _ := store.Load()
+	if loaded[0].Description != "Task 1" {
+		t.Errorf("Expected 'Task 1', got %q", loaded[0].Description)
+	}
+}
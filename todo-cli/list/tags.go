@@ -0,0 +1,188 @@
+package list
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// lowercases a tag and rejects empty ones, whitespace, commas and the +, - and !
+// prefixes used by the REPL and filters
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || strings.ContainsAny(tag, " \t\n,") || strings.ContainsAny(tag[:1], "+-!") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+	}
+	return tag, nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tag)
+	}
+	return out, nil
+}
+
+// sorted, without duplicates, always a new slice: items share tag slices with
+// the snapshots handed out by the actor, so they are never changed in place
+func tagSet(tags []string) []string {
+	set := slices.Clone(tags)
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+func (i Item) HasTag(tag string) bool {
+	return slices.Contains(i.Tags, tag)
+}
+
+// adds and removes tags on the item with id, removing wins when a tag is in both
+func TagItem(items []Item, id int, add, remove []string) ([]Item, error) {
+	add, err := normalizeTags(add)
+	if err != nil {
+		return items, err
+	}
+	if remove, err = normalizeTags(remove); err != nil {
+		return items, err
+	}
+	for i, item := range items {
+		if item.ID == id {
+			tags := slices.DeleteFunc(tagSet(append(slices.Clone(item.Tags), add...)), func(tag string) bool {
+				return slices.Contains(remove, tag)
+			})
+			items[i].Tags = tags
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// replaces all tags of the item with id
+func SetTags(items []Item, id int, tags []string) ([]Item, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return items, err
+	}
+	for i, item := range items {
+		if item.ID == id {
+			items[i].Tags = tagSet(tags)
+			items[i].UpdatedAt = timestamp()
+			return items, nil
+		}
+	}
+	return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+}
+
+// adds an item carrying tags, a subtask when parent is set. Invalid tags add nothing.
+func addTagged(items []Item, description string, parent *int, tags []string) ([]Item, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return items, err
+	}
+	if parent == nil {
+		items = Add(items, description)
+	} else if items, err = AddSubtask(items, *parent, description); err != nil {
+		return items, err
+	}
+	if len(tags) > 0 {
+		items[len(items)-1].Tags = tagSet(tags)
+	}
+	return items, nil
+}
+
+// replaces every tag in from with into and returns the IDs of the items that changed.
+// Renaming is a merge with a single source. No item carrying any of from is ErrNotFound.
+func MergeTags(items []Item, into string, from ...string) ([]Item, []int, error) {
+	into, err := NormalizeTag(into)
+	if err != nil {
+		return items, nil, err
+	}
+	if from, err = normalizeTags(from); err != nil {
+		return items, nil, err
+	}
+
+	var changed []int
+	for i, item := range items {
+		if !slices.ContainsFunc(item.Tags, func(tag string) bool { return slices.Contains(from, tag) }) {
+			continue
+		}
+		tags := make([]string, 0, len(item.Tags))
+		for _, tag := range item.Tags {
+			if slices.Contains(from, tag) {
+				tag = into
+			}
+			tags = append(tags, tag)
+		}
+		items[i].Tags = tagSet(tags)
+		items[i].UpdatedAt = timestamp()
+		changed = append(changed, item.ID)
+	}
+	if len(changed) == 0 {
+		return items, nil, fmt.Errorf("tag %s %w", strings.Join(from, ", "), ErrNotFound)
+	}
+	return items, changed, nil
+}
+
+// keeps the items matching every filter: "backend" requires the tag, "!urgent" excludes it
+func FilterTags(items []Item, filters []string) ([]Item, error) {
+	var include, exclude []string
+	for _, f := range filters {
+		negate := strings.HasPrefix(f, "!")
+		tag, err := NormalizeTag(strings.TrimPrefix(f, "!"))
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			exclude = append(exclude, tag)
+		} else {
+			include = append(include, tag)
+		}
+	}
+
+	matched := []Item{}
+	for _, item := range items {
+		ok := true
+		for _, tag := range include {
+			ok = ok && item.HasTag(tag)
+		}
+		for _, tag := range exclude {
+			ok = ok && !item.HasTag(tag)
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+	return matched, nil
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// how many items carry each tag, most used first
+func TagCounts(items []Item) []TagCount {
+	counts := map[string]int{}
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			counts[tag]++
+		}
+	}
+	cloud := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		cloud = append(cloud, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(cloud, func(a, b int) bool {
+		if cloud[a].Count != cloud[b].Count {
+			return cloud[a].Count > cloud[b].Count
+		}
+		return cloud[a].Tag < cloud[b].Tag
+	})
+	return cloud
+}
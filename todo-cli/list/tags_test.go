@@ -0,0 +1,72 @@
+package list_test
+
+import (
+	"errors"
+	"testing"
+	"todo-cli/list"
+)
+
+func taggedItems() []list.Item {
+	return []list.Item{
+		{ID: 1, Description: "API", Tags: []string{"backend", "urgent"}},
+		{ID: 2, Description: "CSS", Tags: []string{"frontend"}},
+		{ID: 3, Description: "DB", Tags: []string{"be"}},
+		{ID: 4, Description: "Docs"},
+	}
+}
+
+func TestTagItem(t *testing.T) {
+	items, err := list.TagItem(taggedItems(), 1, []string{"Backend", "api"}, []string{"urgent"})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got := items[0].Tags; len(got) != 2 || got[0] != "api" || got[1] != "backend" {
+		t.Errorf("Expected [api backend], got %v", got)
+	}
+
+	for _, bad := range []string{"", "two words", "+plus", "!bang"} {
+		if _, err := list.TagItem(taggedItems(), 1, []string{bad}, nil); !errors.Is(err, list.ErrInvalidTag) {
+			t.Errorf("Expected ErrInvalidTag for %q, got %v", bad, err)
+		}
+	}
+	if _, err := list.TagItem(taggedItems(), 99, []string{"x"}, nil); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	orig := taggedItems()
+	items, changed, err := list.MergeTags(append([]list.Item{}, orig...), "backend", "be", "urgent")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !equalIDs(changed, []int{1, 3}) {
+		t.Errorf("Expected items 1 and 3 to change, got %v", changed)
+	}
+	if got := items[0].Tags; len(got) != 1 || got[0] != "backend" {
+		t.Errorf("Expected the merged tags to collapse into [backend], got %v", got)
+	}
+	if orig[0].Tags[1] != "urgent" {
+		t.Errorf("Merging must not change the tag slices of the original items")
+	}
+
+	if _, _, err := list.MergeTags(taggedItems(), "backend", "missing"); !errors.Is(err, list.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unused tag, got %v", err)
+	}
+}
+
+func TestFilterTagsAndCounts(t *testing.T) {
+	items, err := list.FilterTags(taggedItems(), []string{"!urgent"})
+	if err != nil || len(items) != 3 {
+		t.Errorf("Expected 3 items without urgent, got %d (%v)", len(items), err)
+	}
+	items, _ = list.FilterTags(taggedItems(), []string{"backend", "!frontend"})
+	if len(items) != 1 || items[0].ID != 1 {
+		t.Errorf("Expected only item 1, got %+v", items)
+	}
+
+	cloud := list.TagCounts(append(taggedItems(), list.Item{ID: 5, Tags: []string{"frontend"}}))
+	if len(cloud) != 4 || cloud[0] != (list.TagCount{Tag: "frontend", Count: 2}) || cloud[1].Tag != "backend" {
+		t.Errorf("Unexpected tag counts %+v", cloud)
+	}
+}
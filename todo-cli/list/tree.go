@@ -0,0 +1,174 @@
+package list
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type DeleteMode int
+
+const (
+	// subtasks of a deleted item move up to its parent
+	DeleteReparent DeleteMode = iota
+	// subtasks are deleted with their parent
+	DeleteCascade
+)
+
+func ParseDeleteMode(s string) (DeleteMode, error) {
+	switch s {
+	case "", "reparent":
+		return DeleteReparent, nil
+	case "cascade":
+		return DeleteCascade, nil
+	}
+	return DeleteReparent, fmt.Errorf("unknown delete mode %q, use reparent or cascade", s)
+}
+
+func (i Item) IsSubtask() bool {
+	return i.ParentID != nil
+}
+
+func (i Item) IsChildOf(id int) bool {
+	return i.ParentID != nil && *i.ParentID == id
+}
+
+// adds a subtask of the item with parentID
+func AddSubtask(items []Item, parentID int, description string) ([]Item, error) {
+	if _, ok := FindItem(items, parentID); !ok {
+		return items, fmt.Errorf("%w: no item with ID %d", ErrInvalidParent, parentID)
+	}
+	items = Add(items, description)
+	items[len(items)-1].ParentID = &parentID
+	return items, nil
+}
+
+// moves the item with id under parent, or to the top level when parent is nil.
+// Moving an item under itself or one of its subtasks is ErrCycle.
+func SetParent(items []Item, id int, parent *int) ([]Item, error) {
+	i := indexOf(items, id)
+	if i < 0 {
+		return items, fmt.Errorf("item with ID %d %w", id, ErrNotFound)
+	}
+	if parent != nil {
+		p := *parent
+		if _, ok := FindItem(items, p); !ok {
+			return items, fmt.Errorf("%w: no item with ID %d", ErrInvalidParent, p)
+		}
+		for ancestor := &p; ancestor != nil; {
+			if *ancestor == id {
+				return items, fmt.Errorf("%w: %d is a subtask of %d", ErrCycle, p, id)
+			}
+			next, _ := FindItem(items, *ancestor)
+			ancestor = next.ParentID
+		}
+		parent = &p
+	}
+	items[i].ParentID = parent
+	items[i].UpdatedAt = timestamp()
+	slog.Info("Item parent updated", "id", id, "parent", parent)
+	return items, nil
+}
+
+func sameParent(a, b *int) bool {
+	return a == b || a != nil && b != nil && *a == *b
+}
+
+func indexOf(items []Item, id int) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// IDs of every subtask below id, at any depth
+func descendants(items []Item, id int) []int {
+	var ids []int
+	seen := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for _, item := range items {
+			if item.IsChildOf(parent) && !seen[item.ID] {
+				seen[item.ID] = true
+				ids = append(ids, item.ID)
+				queue = append(queue, item.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// an item positioned in the subtask tree. Done and Total count its subtasks at
+// any depth, so a parent shows e.g. 2/5 done.
+type TreeNode struct {
+	Item
+	Depth int
+	Done  int
+	Total int
+}
+
+// orders items depth first, each subtask right below its parent and siblings in the
+// order they come in. Items whose parent is not in items are shown at the top level,
+// so filtered or sorted subsets still render.
+func Tree(items []Item) []TreeNode {
+	present := map[int]bool{}
+	for _, item := range items {
+		present[item.ID] = true
+	}
+	children := map[int][]Item{}
+	var roots []Item
+	for _, item := range items {
+		if item.ParentID != nil && present[*item.ParentID] && *item.ParentID != item.ID {
+			children[*item.ParentID] = append(children[*item.ParentID], item)
+		} else {
+			roots = append(roots, item)
+		}
+	}
+
+	nodes := make([]TreeNode, 0, len(items))
+	visited := map[int]bool{}
+	var walk func(item Item, depth int) (done, total int)
+	walk = func(item Item, depth int) (done, total int) {
+		visited[item.ID] = true
+		at := len(nodes)
+		nodes = append(nodes, TreeNode{Item: item, Depth: depth})
+		for _, child := range children[item.ID] {
+			if visited[child.ID] {
+				continue
+			}
+			d, t := walk(child, depth+1)
+			done += d
+			total += t + 1
+			if child.IsDone() {
+				done++
+			}
+		}
+		nodes[at].Done, nodes[at].Total = done, total
+		return done, total
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	//items in a parent cycle (only possible in a hand edited file) are never reached from a root
+	for _, item := range items {
+		if !visited[item.ID] {
+			walk(item, 0)
+		}
+	}
+	return nodes
+}
+
+// how many of the subtasks below id (at any depth) are done
+func Progress(items []Item, id int) (done, total int) {
+	for _, child := range descendants(items, id) {
+		item, _ := FindItem(items, child)
+		if item.IsDone() {
+			done++
+		}
+		total++
+	}
+	return done, total
+}
@@ -0,0 +1,106 @@
+package list_test
+
+import (
+	"errors"
+	"testing"
+	"todo-cli/list"
+)
+
+// 0 Launch
+// ├ 1 Design
+// │ └ 2 Mockups
+// └ 3 Build (completed)
+// 4 Docs
+func treeItems(t *testing.T) []list.Item {
+	items := list.Add(nil, "Launch")
+	var err error
+	for _, sub := range []struct {
+		parent int
+		desc   string
+	}{{0, "Design"}, {1, "Mockups"}, {0, "Build"}} {
+		if items, err = list.AddSubtask(items, sub.parent, sub.desc); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	items, _ = list.UpdateStatus(items, 3, list.StatusCompleted)
+	return list.Add(items, "Docs")
+}
+
+func TestTree(t *testing.T) {
+	items := treeItems(t)
+	//parents may come after their subtasks in the slice
+	items[0], items[2] = items[2], items[0]
+
+	var got []int
+	var depths []int
+	for _, node := range list.Tree(items) {
+		got = append(got, node.ID)
+		depths = append(depths, node.Depth)
+		if node.ID == 0 && (node.Done != 1 || node.Total != 3) {
+			t.Errorf("Expected Launch to be 1/3 done, got %d/%d", node.Done, node.Total)
+		}
+	}
+	if !equalIDs(got, []int{0, 1, 2, 3, 4}) || !equalIDs(depths, []int{0, 1, 2, 1, 0}) {
+		t.Errorf("Unexpected tree order %v depths %v", got, depths)
+	}
+
+	if done, total := list.Progress(items, 1); done != 0 || total != 1 {
+		t.Errorf("Expected Design to be 0/1 done, got %d/%d", done, total)
+	}
+
+	//a subtask whose parent was filtered out shows at the top level
+	nodes := list.Tree([]list.Item{items[0]})
+	if len(nodes) != 1 || nodes[0].Depth != 0 {
+		t.Errorf("Expected an orphaned subtask at depth 0, got %+v", nodes)
+	}
+}
+
+func TestSetParent(t *testing.T) {
+	items := treeItems(t)
+	one := 1
+	items, err := list.SetParent(items, 4, &one)
+	if err != nil || !items[4].IsChildOf(1) {
+		t.Errorf("Expected Docs under Design, got %+v (%v)", items[4], err)
+	}
+
+	items, err = list.SetParent(items, 4, nil)
+	if err != nil || items[4].IsSubtask() {
+		t.Errorf("Expected Docs back at the top level, got %+v (%v)", items[4], err)
+	}
+
+	two, zero, missing := 2, 0, 99
+	if _, err := list.SetParent(items, 0, &two); !errors.Is(err, list.ErrCycle) {
+		t.Errorf("Expected ErrCycle moving Launch under its own subtask, got %v", err)
+	}
+	if _, err := list.SetParent(items, 0, &zero); !errors.Is(err, list.ErrCycle) {
+		t.Errorf("Expected ErrCycle moving an item under itself, got %v", err)
+	}
+	if _, err := list.SetParent(items, 0, &missing); !errors.Is(err, list.ErrInvalidParent) {
+		t.Errorf("Expected ErrInvalidParent, got %v", err)
+	}
+	if _, err := list.AddSubtask(items, missing, "Orphan"); !errors.Is(err, list.ErrInvalidParent) {
+		t.Errorf("Expected ErrInvalidParent, got %v", err)
+	}
+}
+
+func TestDelete_Subtasks(t *testing.T) {
+	items := list.Delete(treeItems(t), 1)
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got %d", len(items))
+	}
+	//Mockups moved up to Launch
+	if mockups := items[1]; mockups.ID != 2 || !mockups.IsChildOf(0) {
+		t.Errorf("Expected Mockups under Launch, got %+v", mockups)
+	}
+
+	items = list.Delete(treeItems(t), 0, list.DeleteCascade)
+	if len(items) != 1 || items[0].ID != 4 {
+		t.Errorf("Expected only Docs to remain, got %+v", items)
+	}
+
+	//top level children of a deleted top level item become top level items
+	items = list.Delete(treeItems(t), 0)
+	if items[0].IsSubtask() || !items[1].IsChildOf(1) {
+		t.Errorf("Unexpected parents after delete %+v", items)
+	}
+}
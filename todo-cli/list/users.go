@@ -0,0 +1,160 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// the user of requests that don't name one, the owner of the data saved before there were users
+const DefaultUser = "default"
+
+// lowercases a user name and checks it is 1 to 32 letters, digits, _ or -, so it is
+// safe to use as a directory name
+func NormalizeUserName(name string) (string, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if !listNamePattern.MatchString(n) {
+		return "", fmt.Errorf("%w: %q, use up to 32 letters, digits, _ or -", ErrInvalidUser, name)
+	}
+	return n, nil
+}
+
+// the data file of a user. The default user keeps the data file itself, the others get
+// the same file name in a directory of their own, items.json of "alice" is users/alice/items.json.
+func UserDataFile(dataFile, user string) string {
+	if user == DefaultUser {
+		return dataFile
+	}
+	return filepath.Join(filepath.Dir(dataFile), "users", user, filepath.Base(dataFile))
+}
+
+// names of the users with a directory of their own next to dataFile, default first
+func FindUsers(dataFile string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(dataFile), "users"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	names := []string{DefaultUser}
+	for _, entry := range entries {
+		if entry.IsDir() && listNamePattern.MatchString(entry.Name()) && entry.Name() != DefaultUser {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Users keeps the Lists of every user apart. Each user has their own actors, stores and
+// ID sequences, so an item ID only ever refers to an item of the user asking. Like
+// lists, only Create adds a user, so naming an unknown user leaves nothing behind.
+type Users struct {
+	// names of the users that exist in storage, nil when only the users seen so far count
+	Discover func() ([]string, error)
+
+	mu    sync.Mutex
+	open  func(user string) (*Lists, error)
+	lists map[string]*Lists
+	// what users without data yet read, see View
+	empty *Lists
+}
+
+// open returns the lists of a user, called once per user the first time they are seen
+// and allowed to create their storage
+func NewUsers(open func(user string) (*Lists, error)) *Users {
+	return &Users{open: open, lists: map[string]*Lists{}}
+}
+
+// Users with only the default user, served by lists. Other users are ErrUserNotFound.
+func SingleUser(lists *Lists) *Users {
+	u := NewUsers(nil)
+	u.lists[DefaultUser] = lists
+	return u
+}
+
+// the lists of an existing user, opening them when the user hasn't been seen yet. The
+// default user always exists, any other has to be in storage (see Discover) or created
+// before, else it is ErrUserNotFound.
+func (u *Users) Get(name string) (*Lists, error) {
+	return u.get(name, false)
+}
+
+// the lists of the named user, adding the user when they don't exist yet
+func (u *Users) Create(name string) (*Lists, error) {
+	return u.get(name, true)
+}
+
+// like Get, but a user that doesn't exist yet reads as one with an empty default list
+// and no others, without creating anything. Only for reads: what is written to those
+// lists isn't saved. A server that can't add users still has ErrUserNotFound.
+func (u *Users) View(name string) (*Lists, error) {
+	lists, err := u.Get(name)
+	if !errors.Is(err, ErrUserNotFound) || u.open == nil {
+		return lists, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.empty == nil {
+		u.empty = NewLists(func(string) (Store, error) { return NewMemoryStore(nil), nil }, 0)
+	}
+	return u.empty, nil
+}
+
+func (u *Users) get(name string, create bool) (*Lists, error) {
+	name, err := NormalizeUserName(name)
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if lists, ok := u.lists[name]; ok {
+		return lists, nil
+	}
+	if u.open == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+	}
+	if !create && name != DefaultUser {
+		exists, err := u.exists(name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, name)
+		}
+	}
+
+	lists, err := u.open(name)
+	if err != nil {
+		return nil, err
+	}
+	u.lists[name] = lists
+	slog.Info("User lists opened", "user", name)
+	return lists, nil
+}
+
+// reports whether storage holds the user, without Discover only users seen so far exist
+func (u *Users) exists(name string) (bool, error) {
+	if u.Discover == nil {
+		return false, nil
+	}
+	names, err := u.Discover()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(names, name), nil
+}
+
+// stops the lists of every user
+func (u *Users) Stop() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, lists := range u.lists {
+		lists.Stop()
+	}
+	if u.empty != nil {
+		u.empty.Stop()
+	}
+}
@@ -0,0 +1,81 @@
+package list_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"todo-cli/list"
+)
+
+func TestUserDataFile(t *testing.T) {
+	if got := list.UserDataFile("data/items.json", list.DefaultUser); got != "data/items.json" {
+		t.Errorf("Expected the default user to keep the data file, got %s", got)
+	}
+	if got := list.UserDataFile("data/items.json", "alice"); got != filepath.Join("data", "users", "alice", "items.json") {
+		t.Errorf("Unexpected file for alice: %s", got)
+	}
+}
+
+func TestFindUsers(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "items.json")
+	for _, name := range []string{"alice", "Not A User"} {
+		if err := os.MkdirAll(filepath.Join(dir, "users", name), 0755); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	names, err := list.FindUsers(dataFile)
+	if err != nil || !slices.Equal(names, []string{"default", "alice"}) {
+		t.Errorf("Unexpected users %v (%v)", names, err)
+	}
+}
+
+func TestUsers_Get(t *testing.T) {
+	opened := 0
+	users := list.NewUsers(func(string) (*list.Lists, error) {
+		opened++
+		return list.NewLists(func(string) (list.Store, error) { return list.NewMemoryStore(nil), nil }, 0), nil
+	})
+	defer users.Stop()
+
+	//reading an unknown user opens nothing
+	if _, err := users.Get("alice"); !errors.Is(err, list.ErrUserNotFound) || opened != 0 {
+		t.Errorf("Expected ErrUserNotFound without opening anything, got %v (opened %d)", err, opened)
+	}
+	view, err := users.View("carol")
+	if err != nil || opened != 0 {
+		t.Fatalf("Expected carol's empty lists without opening anything, got %v (opened %d)", err, opened)
+	}
+	if actor, err := view.Get(list.DefaultList); err != nil {
+		t.Errorf("Expected an empty default list, got %v", err)
+	} else if items, _ := actor.GetAll(context.Background()); len(items) != 0 {
+		t.Errorf("Expected no items, got %v", items)
+	}
+	if _, err := view.Get("work"); !errors.Is(err, list.ErrListNotFound) {
+		t.Errorf("Expected ErrListNotFound, got %v", err)
+	}
+	alice, err := users.Create("Alice")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	bob, _ := users.Create("bob")
+	if again, _ := users.Get("alice"); again != alice || alice == bob || opened != 2 {
+		t.Errorf("Expected every user to get their lists opened once, opened %d", opened)
+	}
+	for _, name := range []string{"", "../bob", "a/b"} {
+		if _, err := users.Get(name); !errors.Is(err, list.ErrInvalidUser) {
+			t.Errorf("%q: expected ErrInvalidUser, got %v", name, err)
+		}
+	}
+
+	single := list.SingleUser(alice)
+	if _, err := single.Get("bob"); !errors.Is(err, list.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+	if _, err := single.View("bob"); !errors.Is(err, list.ErrUserNotFound) {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
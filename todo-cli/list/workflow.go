@@ -0,0 +1,216 @@
+package list
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// the statuses items can be in and the moves allowed between them, loaded from a JSON
+// file like workflow.example.json
+type Workflow struct {
+	// every status in order, the first is the one new items start in
+	Statuses []string `json:"statuses"`
+	// statuses work is under way in, moving into one sets StartedAt
+	Active []string `json:"active,omitempty"`
+	// statuses that finish an item (it sets CompletedAt, stops the item being overdue or
+	// blocking others and repeats recurring items)
+	Done []string `json:"done"`
+	// the statuses each status may move to. A status without an entry may move to any
+	// other, an empty entry makes it final.
+	Transitions map[string][]string `json:"transitions,omitempty"`
+}
+
+// not started, started and completed, any move allowed
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []string{StatusNotStarted, StatusStarted, StatusCompleted},
+		Active:   []string{StatusStarted},
+		Done:     []string{StatusCompleted},
+	}
+}
+
+// the workflow the list functions follow, swapped out through SetWorkflow
+var workflow = DefaultWorkflow()
+
+// replaces the workflow used by the list functions and returns a func restoring the previous one.
+// Call it before the actor starts, like SetClock it is not safe to change while items are updated.
+func SetWorkflow(w Workflow) (restore func()) {
+	prev := workflow
+	workflow = w
+	return func() { workflow = prev }
+}
+
+func CurrentWorkflow() Workflow {
+	return workflow
+}
+
+// reads a workflow from a JSON file and validates it
+func LoadWorkflow(filename string) (Workflow, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Workflow{}, err
+	}
+	var w Workflow
+	if err := json.Unmarshal(data, &w); err != nil {
+		return Workflow{}, fmt.Errorf("%w: %s: %v", ErrInvalidWorkflow, filename, err)
+	}
+	if err := w.Validate(); err != nil {
+		return Workflow{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return w.normalize(), nil
+}
+
+// lowercases and trims every status, the form UpdateStatus compares against
+func (w Workflow) normalize() Workflow {
+	clean := func(statuses []string) []string {
+		out := make([]string, len(statuses))
+		for i, s := range statuses {
+			out[i] = strings.ToLower(strings.TrimSpace(s))
+		}
+		return out
+	}
+	n := Workflow{Statuses: clean(w.Statuses), Active: clean(w.Active), Done: clean(w.Done)}
+	if w.Transitions != nil {
+		n.Transitions = make(map[string][]string, len(w.Transitions))
+		for from, to := range w.Transitions {
+			n.Transitions[strings.ToLower(strings.TrimSpace(from))] = clean(to)
+		}
+	}
+	return n
+}
+
+// checks that every status is named once, that active, done and transitions only use
+// known statuses and that at least one status finishes an item
+func (w Workflow) Validate() error {
+	n := w.normalize()
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidWorkflow, fmt.Sprintf(format, args...)))
+	}
+
+	if len(n.Statuses) < 2 {
+		invalid("at least two statuses are needed")
+	}
+	for i, s := range n.Statuses {
+		switch {
+		case s == "":
+			invalid("status %d is empty", i+1)
+		case slices.Index(n.Statuses, s) != i:
+			invalid("status %q is listed twice", s)
+		}
+	}
+	known := func(what, s string) bool {
+		if !slices.Contains(n.Statuses, s) {
+			invalid("%s status %q is not in statuses", what, s)
+			return false
+		}
+		return true
+	}
+	if len(n.Done) == 0 {
+		invalid("at least one done status is needed")
+	}
+	for _, s := range n.Done {
+		if known("done", s) && slices.Contains(n.Active, s) {
+			invalid("status %q can't be both active and done", s)
+		}
+	}
+	for _, s := range n.Active {
+		known("active", s)
+	}
+	if len(n.Statuses) > 0 && n.IsDone(n.Statuses[0]) {
+		invalid("the first status %q is where new items start, it can't be done", n.Statuses[0])
+	}
+	froms := make([]string, 0, len(n.Transitions))
+	for from := range n.Transitions {
+		froms = append(froms, from)
+	}
+	slices.Sort(froms)
+	for _, from := range froms {
+		if known("transition", from) {
+			for _, to := range n.Transitions[from] {
+				known("transition", to)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// the status new items start in
+func (w Workflow) Initial() string {
+	return w.Statuses[0]
+}
+
+func (w Workflow) IsDone(status string) bool {
+	return slices.Contains(w.Done, status)
+}
+
+func (w Workflow) IsActive(status string) bool {
+	return slices.Contains(w.Active, status)
+}
+
+// the statuses an item in status may move to, in workflow order
+func (w Workflow) Next(status string) []string {
+	allowed, ok := w.Transitions[status]
+	next := []string{}
+	for _, s := range w.Statuses {
+		if s != status && (!ok || slices.Contains(allowed, s)) {
+			next = append(next, s)
+		}
+	}
+	return next
+}
+
+// reports whether an item may move from one status to another. Staying in the same
+// status is always allowed, and so is leaving a status the workflow doesn't know.
+func (w Workflow) Allows(from, to string) bool {
+	if from == to || !slices.Contains(w.Statuses, from) {
+		return true
+	}
+	return slices.Contains(w.Next(from), to)
+}
+
+// position of status in the workflow, unknown ones sort last
+func (w Workflow) rank(status string) int {
+	if i := slices.Index(w.Statuses, status); i >= 0 {
+		return i
+	}
+	return len(w.Statuses)
+}
+
+// the item's status is one of the workflow's done statuses
+func (i Item) IsDone() bool {
+	return workflow.IsDone(i.Status)
+}
+
+// a move the workflow doesn't allow. Allowed lists the statuses the item can move to instead.
+type TransitionError struct {
+	ID       int
+	From, To string
+	Allowed  []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("%v: item %d can't move from %q to %q, %q is final", ErrInvalidTransition, e.ID, e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("%v: item %d can't move from %q to %q, valid next statuses: %s",
+		ErrInvalidTransition, e.ID, e.From, e.To, quoteAll(e.Allowed))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// "a", "b"
+func quoteAll(statuses []string) string {
+	quoted := make([]string, len(statuses))
+	for i, s := range statuses {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,125 @@
+package list_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-cli/list"
+)
+
+// the example workflow shipped next to main.go
+func reviewWorkflow(t *testing.T) list.Workflow {
+	t.Helper()
+	wf, err := list.LoadWorkflow("../workflow.example.json")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	restore := list.SetWorkflow(wf)
+	t.Cleanup(restore)
+	return wf
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	wf := reviewWorkflow(t)
+	if wf.Initial() != list.StatusNotStarted || !wf.IsDone("cancelled") || !wf.IsActive("in review") {
+		t.Errorf("Unexpected workflow %+v", wf)
+	}
+	if next := wf.Next("started"); !slicesEqual(next, []string{"in review", "blocked", "cancelled"}) {
+		t.Errorf("Unexpected next statuses %v", next)
+	}
+
+	file := filepath.Join(t.TempDir(), "workflow.json")
+	os.WriteFile(file, []byte(`{"statuses": ["todo", "done"]}`), 0644)
+	if _, err := list.LoadWorkflow(file); !errors.Is(err, list.ErrInvalidWorkflow) {
+		t.Errorf("Expected ErrInvalidWorkflow without a done status, got %v", err)
+	}
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	cases := map[string]list.Workflow{
+		"twice":         {Statuses: []string{"todo", "Todo ", "done"}, Done: []string{"done"}},
+		"unknown done":  {Statuses: []string{"todo", "done"}, Done: []string{"finished"}},
+		"done first":    {Statuses: []string{"done", "todo"}, Done: []string{"done"}},
+		"active done":   {Statuses: []string{"todo", "done"}, Active: []string{"done"}, Done: []string{"done"}},
+		"unknown move":  {Statuses: []string{"todo", "done"}, Done: []string{"done"}, Transitions: map[string][]string{"todo": {"doing"}}},
+		"unknown from":  {Statuses: []string{"todo", "done"}, Done: []string{"done"}, Transitions: map[string][]string{"doing": {"done"}}},
+		"single status": {Statuses: []string{"done"}, Done: []string{"done"}},
+	}
+	for name, wf := range cases {
+		if err := wf.Validate(); !errors.Is(err, list.ErrInvalidWorkflow) {
+			t.Errorf("%s: expected ErrInvalidWorkflow, got %v", name, err)
+		}
+	}
+	if err := list.DefaultWorkflow().Validate(); err != nil {
+		t.Errorf("Expected the default workflow to be valid, got %v", err)
+	}
+}
+
+func TestUpdateStatus_Workflow(t *testing.T) {
+	reviewWorkflow(t)
+	items := list.Add(nil, "Ship it")
+
+	_, err := list.UpdateStatus(items, 0, list.StatusCompleted)
+	var terr *list.TransitionError
+	if !errors.As(err, &terr) || !errors.Is(err, list.ErrInvalidTransition) {
+		t.Fatalf("Expected a TransitionError, got %v", err)
+	}
+	if !slicesEqual(terr.Allowed, []string{"started", "cancelled"}) || !strings.Contains(err.Error(), `valid next statuses: "started", "cancelled"`) {
+		t.Errorf("Expected the valid next statuses in the error, got %v", err)
+	}
+
+	if _, err := list.UpdateStatus(items, 0, "sleeping"); !errors.Is(err, list.ErrInvalidStatus) || !strings.Contains(err.Error(), `"in review"`) {
+		t.Errorf("Expected ErrInvalidStatus listing the workflow statuses, got %v", err)
+	}
+
+	for _, status := range []string{"started", "In Review", "started", "blocked"} {
+		if items, err = list.UpdateStatus(items, 0, status); err != nil {
+			t.Fatalf("Moving to %q: unexpected error %v", status, err)
+		}
+	}
+	//blocked is neither active nor done, the item keeps when it was started
+	if items[0].Status != "blocked" || items[0].StartedAt.IsZero() {
+		t.Errorf("Unexpected item %+v", items[0])
+	}
+
+	items, _ = list.UpdateStatus(items, 0, "cancelled")
+	if !items[0].IsDone() || items[0].CompletedAt.IsZero() {
+		t.Errorf("Expected cancelled to finish the item, got %+v", items[0])
+	}
+	_, err = list.UpdateStatus(items, 0, "started")
+	if !errors.As(err, &terr) || !strings.Contains(err.Error(), `"cancelled" is final`) {
+		t.Errorf("Expected cancelled to be final, got %v", err)
+	}
+}
+
+func TestWorkflow_DoneStatuses(t *testing.T) {
+	reviewWorkflow(t)
+	items := list.Add(list.Add(nil, "Spike"), "Build")
+	items, _ = list.AddBlocker(items, 1, 0)
+	items, _ = list.SetDue(items, 0, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+
+	var err error
+	if items, err = list.UpdateStatus(items, 0, "cancelled"); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	//a cancelled blocker no longer holds anything up, and isn't overdue
+	if _, err := list.UpdateStatus(items, 1, "started"); err != nil {
+		t.Errorf("Expected a cancelled blocker to count as done, got %v", err)
+	}
+	if items[0].IsOverdue(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a cancelled item not to be overdue")
+	}
+
+	//statuses sort in workflow order
+	list.Sort(items, []list.SortKey{{Field: list.SortStatus}})
+	if items[0].ID != 1 {
+		t.Errorf("Expected not started before cancelled, got %+v", items)
+	}
+}
+
+func slicesEqual(a, b []string) bool {
+	return strings.Join(a, "|") == strings.Join(b, "|")
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"bufio"
+	"cmp"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
@@ -10,12 +13,23 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"todo-cli/api"
+	"todo-cli/auth"
 	"todo-cli/list"
+	"todo-cli/list/query"
+)
+
+// ANSI escapes used to highlight overdue items
+const (
+	colorRed   = "\033[31m"
+	colorReset = "\033[0m"
 )
 
 func printItems(items []list.Item) {
@@ -23,23 +37,218 @@ func printItems(items []list.Item) {
 		fmt.Println("No items found")
 		return
 	}
+	now := list.Now()
 	fmt.Println("")
 	fmt.Println("To-Do list:")
-	fmt.Printf("%-5s %-12s %s\n", "ID", "STATUS", "Description")
-	fmt.Println(strings.Repeat("-", 50))
-	for _, item := range items {
-		fmt.Printf("%-5d %-12s %s\n", item.ID, item.Status, item.Description)
+	fmt.Printf("%-5s %-4s %-12s %-16s %-16s %-16s %-16s %-16s %s\n", "ID", "PRI", "STATUS", "DUE", "CREATED", "UPDATED", "STARTED", "COMPLETED", "Description")
+	fmt.Println(strings.Repeat("-", 142))
+	//subtasks are indented below their parent, parents show how many of them are done
+	for _, item := range list.Tree(items) {
+		desc := item.Description
+		if item.Depth > 0 {
+			desc = strings.Repeat("   ", item.Depth-1) + "└─ " + desc
+		}
+		if item.Total > 0 {
+			desc += fmt.Sprintf(" (%d/%d)", item.Done, item.Total)
+		}
+		priority := item.Priority
+		if priority == "" {
+			priority = "-"
+		}
+		line := fmt.Sprintf("%-5d %-4s %-12s %-16s %-16s %-16s %-16s %-16s %s", item.ID, priority, item.Status,
+			list.FormatTime(item.DueAt), list.FormatTime(item.CreatedAt), list.FormatTime(item.UpdatedAt),
+			list.FormatTime(item.StartedAt), list.FormatTime(item.CompletedAt), desc)
+		for _, tag := range item.Tags {
+			line += " #" + tag
+		}
+		if item.Repeat != "" {
+			line += " (repeats " + item.Repeat + ")"
+		}
+		if open := list.OpenBlockers(items, item.ID); len(open) > 0 {
+			line += fmt.Sprintf(" (blocked by %v)", open)
+		}
+		if item.IsOverdue(now) {
+			line = colorRed + line + " (overdue)" + colorReset
+		}
+		fmt.Println(line)
 	}
 	fmt.Println()
 }
 
+// prints the blockers of an item as a tree, deepest ones indented the most
+func printDependencies(deps list.Dependencies) {
+	if deps.Ready {
+		fmt.Printf("Item %d is ready to start\n", deps.ID)
+	} else {
+		fmt.Printf("Item %d is blocked\n", deps.ID)
+	}
+	if len(deps.Chain) == 0 {
+		fmt.Println("Waits on: nothing")
+	} else {
+		fmt.Println("Waits on:")
+		for _, node := range deps.Chain {
+			fmt.Printf("%s%d %s [%s]\n", strings.Repeat("   ", node.Depth), node.ID, node.Description, node.Status)
+		}
+	}
+	if len(deps.Blocks) == 0 {
+		fmt.Println("Blocks: nothing")
+		return
+	}
+	fmt.Println("Blocks:")
+	for _, item := range deps.Blocks {
+		fmt.Printf("   %d %s [%s]\n", item.ID, item.Description, item.Status)
+	}
+}
+
+// key create|list|revoke, managing the API keys of the HTTP API
+func runKeyCommand(keys *auth.Keys, args []string, user string) {
+	usage := "Usage: key create <name> <read|write|admin> [user] | key list | key revoke <id>"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return
+	}
+	switch args[0] {
+	case "create":
+		if len(args) < 3 || len(args) > 4 {
+			fmt.Println("Usage: key create <name> <read|write|admin> [user]")
+			return
+		}
+		if len(args) == 4 {
+			user = args[3]
+		}
+		token, key, err := keys.Create(args[1], user, auth.Scope(args[2]))
+		if err != nil {
+			fmt.Println("Error: ", err)
+			slog.Error("Create API key failed", "error", err)
+			return
+		}
+		fmt.Printf("Created %s key %s for user %s:\n\n    %s\n\nStore it now, it can't be shown again.\n", key.Scope, key.ID, key.User, token)
+
+	case "list":
+		all, err := keys.List()
+		if err != nil {
+			fmt.Println("Error: ", err)
+			slog.Error("List API keys failed", "error", err)
+			return
+		}
+		if len(all) == 0 {
+			fmt.Println("No API keys, create one with key create")
+			return
+		}
+		fmt.Printf("%-14s%-20s%-16s%-8s%-18s%s\n", "ID", "NAME", "USER", "SCOPE", "CREATED", "REVOKED")
+		for _, key := range all {
+			revoked := "-"
+			if key.Revoked() {
+				revoked = list.FormatTime(*key.RevokedAt)
+			}
+			fmt.Printf("%-14s%-20s%-16s%-8s%-18s%s\n", key.ID, key.Name, key.User, key.Scope, list.FormatTime(key.CreatedAt), revoked)
+		}
+
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Println("Usage: key revoke <id>")
+			return
+		}
+		key, err := keys.Revoke(args[1])
+		if err != nil {
+			fmt.Println("Error: ", err)
+			slog.Error("Revoke API key failed", "id", args[1], "error", err)
+			return
+		}
+		fmt.Printf("Revoked key %s (%s)\n", key.ID, key.Name)
+
+	default:
+		fmt.Println(usage)
+	}
+}
+
+// picks the persistence backend selected on the command line
+func openStore(kind, filename string, lockTimeout time.Duration) (list.Store, error) {
+	switch kind {
+	case "file":
+		return list.NewFileStore(filename, lockTimeout), nil
+	case "journal":
+		return list.OpenJournalStore(filename, list.DefaultCompactEvery)
+	case "memory":
+		return list.NewMemoryStore(nil), nil
+	default:
+		return nil, fmt.Errorf("unknown store %q (use file, journal or memory)", kind)
+	}
+}
+
 func main() {
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
-	filename := list.DefaultDataFile
-	items := list.LoadFromFile(filename)
+	storeKind := flag.String("store", "file", "persistence backend: file, journal or memory")
+	dataFile := flag.String("data", list.DefaultDataFile, "path of the JSON data file")
+	lockTimeout := flag.Duration("lock-timeout", list.DefaultLockTimeout, "how long to wait for another process holding the data file")
+	groupCommit := flag.Duration("group-commit", 0, "batch all changes within this window into one save (0 saves every change)")
+	workflowFile := flag.String("workflow", "", "JSON file with custom statuses and the transitions allowed between them")
+	userName := flag.String("user", list.DefaultUser, "the user whose lists the commands work on, each user keeps their items in users/<name>")
+	keysFile := flag.String("keys", auth.DefaultKeysFile, "file with the hashed API keys of the HTTP API")
+	authOn := flag.Bool("auth", true, "require an API key on every HTTP request (-auth=false serves the API openly)")
+	requireUser := flag.Bool("require-user", false, "reject API requests without an X-User header instead of serving them as the default user")
+	trustUserHeader := flag.Bool("trust-user-header", false, "take the user of API requests without a key from their X-User header, only behind a proxy that authenticates users and sets it")
+	flag.Parse()
+
+	if *workflowFile != "" {
+		wf, err := list.LoadWorkflow(*workflowFile)
+		if err != nil {
+			fmt.Println("Error: ", err)
+			slog.Error("Could not load workflow", "file", *workflowFile, "error", err)
+			os.Exit(1)
+		}
+		list.SetWorkflow(wf)
+		slog.Info("Workflow loaded", "file", *workflowFile, "statuses", wf.Statuses)
+	}
+
+	keys, err := auth.LoadKeys(*keysFile)
+	if err != nil {
+		fmt.Println("Error: ", err)
+		slog.Error("Could not load API keys", "file", *keysFile, "error", err)
+		os.Exit(1)
+	}
+
+	//every user has their own lists and every list its own store, the default ones keep the data file
+	users := list.NewUsers(func(user string) (*list.Lists, error) {
+		userFile := list.UserDataFile(*dataFile, user)
+		if *storeKind != "memory" {
+			if err := os.MkdirAll(filepath.Dir(userFile), 0755); err != nil {
+				return nil, err
+			}
+		}
+		lists := list.NewLists(func(name string) (list.Store, error) {
+			return openStore(*storeKind, list.ListFile(userFile, name), *lockTimeout)
+		}, *groupCommit)
+		if *storeKind != "memory" {
+			lists.Discover = func() ([]string, error) { return list.FindLists(userFile) }
+		}
+		lists.Recovered = func(name string, err error) {
+			fmt.Printf("Warning: list %s: %v\n", name, err)
+		}
+		return lists, nil
+	})
+	if *storeKind != "memory" {
+		users.Discover = func() ([]string, error) { return list.FindUsers(*dataFile) }
+	}
+	lists, err := users.Create(*userName)
+	if err != nil {
+		fmt.Println("Error: ", err)
+		slog.Error("Could not open the user's lists", "user", *userName, "error", err)
+		os.Exit(1)
+	}
+	//refuse to start on a corrupt file, the first save would overwrite it with an empty list
+	current := list.DefaultList
+	actor, err := lists.Get(current)
+	if err != nil {
+		fmt.Println("Error: ", err)
+		slog.Error("Could not load items", "store", *storeKind, "error", err)
+		os.Exit(1)
+	}
+	//final flush of anything still waiting for a group commit
+	defer users.Stop()
 	scanner := bufio.NewScanner(os.Stdin)
 
 	slog.Info("Application Started")
@@ -53,12 +262,18 @@ func main() {
 	go func() {
 		<-ctx.Done()
 		slog.Info("Graceful shutdown signal received - Closing Application...")
+		users.Stop()
 		stop()
 		os.Exit(0)
 	}()
 
 	for {
-		fmt.Print("> ")
+		//the prompt names the list in use unless it is the default one
+		if current == list.DefaultList {
+			fmt.Print("> ")
+		} else {
+			fmt.Printf("%s> ", current)
+		}
 		if !scanner.Scan() {
 			break
 		}
@@ -75,17 +290,52 @@ func main() {
 			fmt.Println(`
 Available Commands:
 	add <Descriptio>					- Add a new to-do item
-	list							- Shows the entire list
+	add --parent <id> <description>				- Add a subtask of an item
+	reparent <id> <parent id|none>				- Move an item under another one, or back to the top level
+	list [--sort <keys>]					- Shows the entire list, e.g. --sort priority,-created (priority, status, due, created, id)
 	update <id> description <new descriptio>		- Update item description
-	update <id> status <new status>				- Update item status (started, not started, completed)
-	delete <id>						- Delete an item
-	server							- Start HTTP Json API on port 8080
+	update <id> status <new status> [--force]		- Update item status (see workflow), --force ignores open blockers
+	update <id> notes <text>				- Set the item notes
+	update <id> due <YYYY-MM-DD|RFC 3339|none>		- Set or clear the item due date
+	update <id> priority <P0-P3|none>			- Set or clear the item priority (P0 is the most urgent)
+	repeat <id> <rule|none>					- Repeat an item once completed: daily, weekly, monthly or an RRULE like
+								  FREQ=WEEKLY;BYDAY=MO,TH, FREQ=MONTHLY;BYMONTHDAY=-1, FREQ=DAILY;INTERVAL=3;X-FROM=COMPLETION
+	search <words>						- Full-text search of descriptions and notes, best matches first
+	find <query>						- Search, e.g. find status:started tag:backend due<2026-11-01 "login bug"
+	upcoming [days]						- Shows open items due in the next days (default 7), overdue included
+	tag <id> +<tag> -<tag> ...				- Add and remove tags on an item
+	tag rename <from> <to>					- Rename a tag on every item
+	tag merge <into> <from> ...				- Merge tags into one on every item
+	tags							- Shows every tag with its item count
+	block <id> <blocker id> ...				- Make an item wait on other items
+	unblock <id> <blocker id> ...				- Stop an item waiting on other items
+	deps <id>						- Shows what an item waits on and what waits on it
+	next [--all]						- Shows the open items that can be worked on now, --all the whole plan by level
+	delete <id> [--cascade]					- Delete an item, its subtasks move up unless --cascade deletes them too
+	workflow						- Shows the statuses and where each one can move next
+	lists							- Shows every list with its item count, * marks the one in use
+	use <list>						- Switch to another list, a new name creates it
+	mv <id> <list>						- Move an item to another list, where it gets a new ID
+	key create <name> <read|write|admin> [user]		- Create an API key for a user (default the -user one), shown only once
+	key list						- Shows every API key with its user and scope
+	key revoke <id>						- Revoke an API key, requests using it get 401
+	server							- Start HTTP Json API on port 8080, requests need an API key (see -auth)
 	exit							- Exit the application
 		`)
 
 		case "server":
 			fmt.Println("Starting HTTP server on http://localhost:8080")
-			go api.StartServer() //Starts the server
+			var serverKeys *auth.Keys
+			if *authOn {
+				serverKeys = keys
+				if active, err := keys.List(); err == nil && !slices.ContainsFunc(active, func(k auth.Key) bool { return !k.Revoked() }) {
+					fmt.Println("No API keys yet, every request gets 401 until one is created with 'key create'")
+				}
+			}
+			if *requireUser && !*authOn && !*trustUserHeader {
+				fmt.Println("-require-user without API keys needs -trust-user-header, every request gets 401 until then")
+			}
+			go api.StartServer(users, *requireUser, *trustUserHeader, serverKeys) //Starts the server
 
 			go func() {
 				log.Println("pprof listening on :6060")
@@ -97,17 +347,109 @@ Available Commands:
 
 		case "add":
 			if len(args) < 2 {
-				fmt.Println("Usage: add <description>")
+				fmt.Println("Usage: add [--parent <id>] <description>")
+				continue
+			}
+			if args[1] == "--parent" {
+				if len(args) < 4 {
+					fmt.Println("Usage: add --parent <id> <description>")
+					continue
+				}
+				parent, err := strconv.Atoi(args[2])
+				if err != nil {
+					fmt.Println("Invalid parent ID")
+					continue
+				}
+				if _, err := actor.AddSubtask(ctx, parent, strings.Join(args[3:], " ")); err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Add subtask failed", "parent", parent, "error", err)
+					continue
+				}
+				fmt.Println("Subtask added")
 				continue
 			}
 			desc := strings.Join(args[1:], " ")
-			items = list.Add(items, desc)
-			list.SaveToFile(filename, items)
+			if _, err := actor.Add(ctx, desc); err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Add item failed", "error", err)
+				continue
+			}
 			fmt.Println("Item added")
 
 		case "list":
+			var keys []list.SortKey
+			if len(args) > 1 {
+				if len(args) != 3 || args[1] != "--sort" {
+					fmt.Println("Usage: list [--sort priority,-created]")
+					continue
+				}
+				if keys, err = list.ParseSort(args[2]); err != nil {
+					fmt.Println("Error: ", err)
+					continue
+				}
+			}
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			list.Sort(items, keys)
+			printItems(items)
+
+		case "search":
+			if len(args) < 2 {
+				fmt.Println("Usage: search <words>")
+				continue
+			}
+			items, err := actor.Search(ctx, strings.Join(args[1:], " "), 0)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Search failed", "error", err)
+				continue
+			}
 			printItems(items)
 
+		case "find":
+			if len(args) < 2 {
+				fmt.Println(`Usage: find <query>, e.g. find status:started tag:backend due<2026-11-01 "login bug"`)
+				continue
+			}
+			//the raw text keeps quotes and spacing, so error positions line up with what was typed
+			q := strings.TrimSpace(input[len(args[0]):])
+			node, err := query.Parse(q)
+			if err != nil {
+				var perr *query.ParseError
+				if errors.As(err, &perr) {
+					fmt.Println(perr.Pointer())
+				}
+				fmt.Println("Error: ", err)
+				continue
+			}
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			printItems(query.Filter(items, node))
+
+		case "upcoming":
+			days := 7
+			if len(args) > 1 {
+				if days, err = strconv.Atoi(args[1]); err != nil || days < 0 {
+					fmt.Println("Usage: upcoming [days]")
+					continue
+				}
+			}
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			printItems(list.Upcoming(items, list.Now(), days))
+
 		case "update":
 			if len(args) < 4 {
 				fmt.Println("Usage: update <id> description|status <value>")
@@ -125,34 +467,245 @@ Available Commands:
 
 			switch field {
 			case "description":
-				updatedItems, err := list.UpdateDescription(items, id, value)
-				if err != nil {
+				if _, err := actor.UpdateDescription(ctx, id, value); err != nil {
 					fmt.Println("Error: ", err)
 					slog.Error("Update description failed", "id", id, "error", err)
 					continue
 				}
-				items = updatedItems
-				list.SaveToFile(filename, items)
 				fmt.Println("Description updated")
 
 			case "status":
-				updateItems, err := list.UpdateStatus(items, id, value)
-				if err != nil {
+				//--force starts or completes the item even when it is still blocked
+				force := strings.HasSuffix(value, " --force")
+				value = strings.TrimSuffix(value, " --force")
+				if _, err := actor.Patch(ctx, id, list.ItemPatch{Status: &value, Force: force}); err != nil {
 					fmt.Println("Error: ", err)
 					slog.Error("Update status failed", "id", id, "error", err)
 					continue
 				}
-				items = updateItems
-				list.SaveToFile(list.DefaultDataFile, items)
 				fmt.Println("Status updated")
 
+			case "notes":
+				//notes keep their case
+				notes := strings.Join(args[3:], " ")
+				if _, err := actor.Patch(ctx, id, list.ItemPatch{Notes: &notes}); err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Update notes failed", "id", id, "error", err)
+					continue
+				}
+				fmt.Println("Notes updated")
+
+			case "due":
+				//dates are case sensitive (the T and Z of RFC 3339), use the raw input
+				due := strings.Join(args[3:], " ")
+				if _, err := actor.Patch(ctx, id, list.ItemPatch{Due: &due}); err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Update due date failed", "id", id, "error", err)
+					continue
+				}
+				fmt.Println("Due date updated")
+
+			case "priority":
+				if _, err := actor.Patch(ctx, id, list.ItemPatch{Priority: &value}); err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Update priority failed", "id", id, "error", err)
+					continue
+				}
+				fmt.Println("Priority updated")
+
 			default:
-				fmt.Println("Invalid update field. Please use one of the following options: `description`, or `status`")
+				fmt.Println("Invalid update field. Please use one of the following options: `description`, `status`, `notes`, `due` or `priority`")
 			}
 
-		case "delete":
+		case "tag":
+			if len(args) < 3 {
+				fmt.Println("Usage: tag <id> +<tag> -<tag> ... | tag rename <from> <to> | tag merge <into> <from> ...")
+				continue
+			}
+			switch args[1] {
+			case "rename", "merge":
+				if len(args) < 4 || (args[1] == "rename" && len(args) != 4) {
+					fmt.Println("Usage: tag rename <from> <to> | tag merge <into> <from> ...")
+					continue
+				}
+				var err error
+				if args[1] == "rename" {
+					_, err = actor.RenameTag(ctx, args[2], args[3])
+				} else {
+					_, err = actor.MergeTags(ctx, args[2], args[3:]...)
+				}
+				if err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Tag command failed", "tag", args[1], "args", args[2:], "error", err)
+					continue
+				}
+				fmt.Println("Tags updated")
+
+			default:
+				id, err := strconv.Atoi(args[1])
+				if err != nil {
+					fmt.Println("Invalid ID")
+					slog.Error("Invalid tag ID", "input", args[1], "error", err)
+					continue
+				}
+				var patch list.ItemPatch
+				for _, arg := range args[2:] {
+					if tag, ok := strings.CutPrefix(arg, "-"); ok {
+						patch.RemoveTags = append(patch.RemoveTags, tag)
+					} else {
+						patch.AddTags = append(patch.AddTags, strings.TrimPrefix(arg, "+"))
+					}
+				}
+				if _, err := actor.Patch(ctx, id, patch); err != nil {
+					fmt.Println("Error: ", err)
+					slog.Error("Tag item failed", "id", id, "error", err)
+					continue
+				}
+				fmt.Println("Tags updated")
+			}
+
+		case "tags":
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			cloud := list.TagCounts(items)
+			if len(cloud) == 0 {
+				fmt.Println("No tags found")
+				continue
+			}
+			for _, tc := range cloud {
+				fmt.Printf("%-20s %d\n", tc.Tag, tc.Count)
+			}
+
+		case "reparent":
+			if len(args) != 3 {
+				fmt.Println("Usage: reparent <id> <parent id|none>")
+				continue
+			}
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("Invalid ID")
+				continue
+			}
+			//a negative parent moves the item to the top level
+			parent := -1
+			if args[2] != "none" {
+				if parent, err = strconv.Atoi(args[2]); err != nil || parent < 0 {
+					fmt.Println("Invalid parent ID")
+					continue
+				}
+			}
+			if _, err := actor.Patch(ctx, id, list.ItemPatch{ParentID: &parent}); err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Move item failed", "id", id, "parent", parent, "error", err)
+				continue
+			}
+			fmt.Println("Item moved")
+
+		case "repeat":
+			if len(args) != 3 {
+				fmt.Println("Usage: repeat <id> <daily|weekly|monthly|RRULE|none>")
+				continue
+			}
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("Invalid ID")
+				continue
+			}
+			if _, err := actor.Patch(ctx, id, list.ItemPatch{Repeat: &args[2]}); err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Update recurrence failed", "id", id, "rule", args[2], "error", err)
+				continue
+			}
+			fmt.Println("Recurrence updated")
+
+		case "block", "unblock":
+			if len(args) < 3 {
+				fmt.Printf("Usage: %s <id> <blocker id> ...\n", command)
+				continue
+			}
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("Invalid ID")
+				continue
+			}
+			var blockers []int
+			for _, arg := range args[2:] {
+				blocker, err := strconv.Atoi(arg)
+				if err != nil {
+					blockers = nil
+					break
+				}
+				blockers = append(blockers, blocker)
+			}
+			if blockers == nil {
+				fmt.Println("Invalid blocker ID")
+				continue
+			}
+			patch := list.ItemPatch{AddBlockedBy: blockers}
+			if command == "unblock" {
+				patch = list.ItemPatch{RemoveBlockedBy: blockers}
+			}
+			if _, err := actor.Patch(ctx, id, patch); err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Update dependencies failed", "id", id, "blockers", blockers, "error", err)
+				continue
+			}
+			fmt.Println("Dependencies updated")
+
+		case "deps":
 			if len(args) != 2 {
-				fmt.Println("Usage: delete <id>")
+				fmt.Println("Usage: deps <id>")
+				continue
+			}
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("Invalid ID")
+				continue
+			}
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			deps, err := list.DependenciesOf(items, id)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				continue
+			}
+			printDependencies(deps)
+
+		case "next":
+			if len(args) > 2 || (len(args) == 2 && args[1] != "--all") {
+				fmt.Println("Usage: next [--all]")
+				continue
+			}
+			items, err := actor.GetAll(ctx)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Load items failed", "error", err)
+				continue
+			}
+			if len(args) == 1 {
+				printItems(list.Next(items))
+				continue
+			}
+			plan := list.Plan(items)
+			if len(plan) == 0 {
+				fmt.Println("No items found")
+				continue
+			}
+			for _, step := range plan {
+				fmt.Printf("%-6s %-5d %-4s %-12s %s\n", fmt.Sprintf("L%d", step.Level), step.ID, cmp.Or(step.Priority, "-"), step.Status, step.Description)
+			}
+
+		case "delete":
+			if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "--cascade") {
+				fmt.Println("Usage: delete <id> [--cascade]")
 				continue
 			}
 			id, err := strconv.Atoi(args[1])
@@ -161,11 +714,102 @@ Available Commands:
 				slog.Error("Invalid delete ID", "input", args[1], "error", err)
 				continue
 			}
+			mode := list.DeleteReparent
+			if len(args) == 3 {
+				mode = list.DeleteCascade
+			}
 
-			items = list.Delete(items, id)
-			list.SaveToFile(filename, items)
+			if _, err := actor.Delete(ctx, id, mode); err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Delete item failed", "id", id, "error", err)
+				continue
+			}
 			fmt.Println("Item deleted")
 
+		case "lists":
+			names, err := lists.Names()
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Listing lists failed", "error", err)
+				continue
+			}
+			for _, name := range names {
+				la, err := lists.Get(name)
+				if err != nil {
+					fmt.Printf("  %-20s error: %v\n", name, err)
+					continue
+				}
+				items, err := la.GetAll(ctx)
+				if err != nil {
+					fmt.Printf("  %-20s error: %v\n", name, err)
+					continue
+				}
+				mark := " "
+				if name == current {
+					mark = "*"
+				}
+				fmt.Printf("%s %-20s %d items\n", mark, name, len(items))
+			}
+
+		case "key":
+			runKeyCommand(keys, args[1:], *userName)
+
+		case "use":
+			if len(args) != 2 {
+				fmt.Println("Usage: use <list>")
+				continue
+			}
+			name, err := list.NormalizeListName(args[1])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				continue
+			}
+			la, err := lists.Create(name)
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Open list failed", "list", name, "error", err)
+				continue
+			}
+			actor, current = la, name
+			fmt.Println("Using list", name)
+
+		case "mv":
+			if len(args) != 3 {
+				fmt.Println("Usage: mv <id> <list>")
+				continue
+			}
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("Invalid ID")
+				continue
+			}
+			moved, err := lists.Move(ctx, id, current, args[2])
+			if err != nil {
+				fmt.Println("Error: ", err)
+				slog.Error("Move to list failed", "id", id, "list", args[2], "error", err)
+				continue
+			}
+			fmt.Printf("Item moved to %s as %d\n", strings.ToLower(args[2]), moved.ID)
+
+		case "workflow":
+			wf := list.CurrentWorkflow()
+			for _, status := range wf.Statuses {
+				kind := ""
+				switch {
+				case status == wf.Initial():
+					kind = " (initial)"
+				case wf.IsActive(status):
+					kind = " (active)"
+				case wf.IsDone(status):
+					kind = " (done)"
+				}
+				next := strings.Join(wf.Next(status), ", ")
+				if next == "" {
+					next = "-"
+				}
+				fmt.Printf("%-24s -> %s\n", status+kind, next)
+			}
+
 		case "exit":
 			fmt.Println("Closing application...")
 			return
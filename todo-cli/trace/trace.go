@@ -0,0 +1,23 @@
+// Package trace carries the request TraceID through contexts so every layer
+// (api handlers, the list actor) can add it to its logs
+package trace
+
+import "context"
+
+type contextkey string
+
+const traceIDKey contextkey = "traceID"
+
+// returns a copy of ctx carrying traceID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// extract the TraceID from context,when present
+func GetTraceID(ctx context.Context) string {
+	traceID, ok := ctx.Value(traceIDKey).(string)
+	if !ok {
+		return "no-trace"
+	}
+	return traceID
+}